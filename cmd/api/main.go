@@ -1,27 +1,211 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
+	_ "time/tzdata" // fuseaux IANA embarqués (serveurs sans /usr/share/zoneinfo)
 
 	httpserver "gestionsvc/internal/transport/http"
+	"gestionsvc/internal/mail"
+	"gestionsvc/internal/notify"
 	"gestionsvc/internal/repository"
 	"gestionsvc/internal/services"
 )
 
+// sessionSecret lit la clé HMAC des sessions dans SESSION_SECRET.
+// À défaut, une clé aléatoire est générée : les sessions ne survivent
+// alors pas à un redémarrage du serveur.
+func sessionSecret() []byte {
+	if v := os.Getenv("SESSION_SECRET"); v != "" {
+		return []byte(v)
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatal(err)
+	}
+	log.Println("SESSION_SECRET not set: using a random key, sessions will not survive a restart")
+	return b
+}
+
+// newMailer choisit l'envoi des emails : SMTP si -smtp-addr est renseigné
+// (identifiants dans SMTP_USERNAME / SMTP_PASSWORD), sinon écriture des
+// messages dans le dossier -mail-dir ou, à défaut, dans la console.
+func newMailer(smtpAddr, from, mailDir string) mail.Mailer {
+	if smtpAddr != "" {
+		return &mail.SMTPMailer{
+			Addr:     smtpAddr,
+			From:     from,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+		}
+	}
+
+	m := mail.NewFileMailer(mailDir)
+	m.From = from
+	return m
+}
+
+// parseOffsets lit une liste de durées séparées par des virgules ("24h,1h").
+func parseOffsets(s string) ([]time.Duration, error) {
+	var out []time.Duration
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := time.ParseDuration(part)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid reminder offset %q", part)
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// openRepository choisit l'implémentation du Repository selon le flag -store.
+func openRepository(kind, dataDir, dbPath string) (services.Repository, error) {
+	switch kind {
+	case "json":
+		return repository.NewJSONStore(dataDir)
+	case "sqlite":
+		return repository.NewSQLStore(dbPath)
+	default:
+		return nil, fmt.Errorf("unknown store %q (use json or sqlite)", kind)
+	}
+}
+
 func main() {
-	// Repo JSON 
-	repo, err := repository.NewJSONStore("data")
+	storeKind := flag.String("store", "json", "stockage des données : json ou sqlite")
+	dataDir := flag.String("data", "data", "dossier des fichiers JSON (store json)")
+	dbPath := flag.String("db", "data/gestion.db", "fichier de la base SQLite (store sqlite)")
+	checkOnly := flag.Bool("check-integrity", false, "affiche les données orphelines puis quitte")
+	sessionTTL := flag.Duration("session-ttl", 24*time.Hour, "durée de validité d'une session")
+	secureCookie := flag.Bool("secure-cookie", false, "cookie de session réservé à HTTPS")
+	baseURL := flag.String("base-url", "http://localhost:8080", "adresse publique du serveur (liens envoyés par email)")
+	loginLinkTTL := flag.Duration("login-link-ttl", 15*time.Minute, "durée de validité d'un lien de connexion")
+	smtpAddr := flag.String("smtp-addr", "", "serveur SMTP hôte:port (vide = emails écrits localement)")
+	mailFrom := flag.String("mail-from", "no-reply@localhost", "expéditeur des emails")
+	mailDir := flag.String("mail-dir", "", "dossier où écrire les emails sans SMTP (vide = console)")
+	mailLang := flag.String("mail-lang", notify.LangFR, "langue des emails de réservation : fr ou en")
+	reminders := flag.String("reminders", "24h,1h", "rappels avant chaque créneau (vide = désactivés)")
+	reminderEvery := flag.Duration("reminder-interval", time.Minute, "fréquence de recherche des rappels à envoyer")
+	holdTTL := flag.Duration("hold-ttl", services.DefaultHoldTTL, "durée pendant laquelle une place reste retenue")
+	holdSweep := flag.Duration("hold-sweep-interval", 30*time.Second, "fréquence de suppression des places retenues expirées")
+	timeZone := flag.String("tz", "Europe/Paris", "fuseau horaire IANA des services qui n'en précisent pas")
+	maxActive := flag.Int("max-active", 0, "réservations à venir maximum par utilisateur (0 = pas de limite)")
+	maxPerService := flag.Int("max-per-service", 0, "réservations à venir maximum par utilisateur et par service (0 = pas de limite)")
+	maxPerDay := flag.Int("max-per-day", 0, "réservations maximum par utilisateur sur un même jour (0 = pas de limite)")
+	minLeadTime := flag.Duration("min-lead-time", 0, "délai minimum entre la réservation et le début du créneau")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 15*time.Second, "délai laissé aux requêtes en cours à l'arrêt du serveur")
+	flag.Parse()
+
+	loc, err := time.LoadLocation(*timeZone)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	limits := services.BookingLimits{
+		MaxActive:     *maxActive,
+		MaxPerService: *maxPerService,
+		MaxPerDay:     *maxPerDay,
+		MinLeadTime:   *minLeadTime,
+	}
+	if err := limits.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Repository (JSON ou SQLite)
+	repo, err := openRepository(*storeKind, *dataDir, *dbPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Vérification d'intégrité hors ligne
+	if *checkOnly {
+		report, err := repo.CheckIntegrity(false)
+		if err != nil {
+			log.Fatal(err)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+		return
+	}
+
+	// Emails : liens de connexion et notifications de réservation
+	mailer := newMailer(*smtpAddr, *mailFrom, *mailDir)
+	notifier := notify.New(notify.Config{
+		Sender:   mailer,
+		BaseURL:  *baseURL,
+		Lang:     *mailLang,
+		Location: loc,
+	})
+
 	// Service métier
-	booking := services.NewBookingService(repo)
+	booking := services.NewBookingService(repo,
+		services.WithNotifier(notifier),
+		services.WithHoldTTL(*holdTTL),
+		services.WithTimeZone(loc),
+		services.WithBookingLimits(limits),
+	)
+
+	// Arrêt propre sur SIGINT / SIGTERM : ctx est annulé à la réception
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Tâches de fond, attendues avant de fermer le notifier et le stockage
+	var background sync.WaitGroup
+
+	// Rappels avant les créneaux (en arrière-plan)
+	offsets, err := parseOffsets(*reminders)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(offsets) > 0 {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			booking.RunReminders(ctx, *reminderEvery, offsets)
+		}()
+	}
+
+	// Places retenues expirées (en arrière-plan)
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		booking.RunHoldSweeper(ctx, *holdSweep)
+	}()
+
+	// Compte administrateur (mot de passe fourni par ADMIN_PASSWORD)
+	if pw := os.Getenv("ADMIN_PASSWORD"); pw != "" {
+		if err := booking.EnsureUser("admin@example.com", pw, services.RoleAdmin); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	// Serveur HTTP (API)
-	srv := httpserver.NewServer(booking)
+	srv := httpserver.NewServer(booking, httpserver.AuthConfig{
+		Secret:       sessionSecret(),
+		SessionTTL:   *sessionTTL,
+		SecureCookie: *secureCookie,
+		Mailer:       mailer,
+		BaseURL:      *baseURL,
+		LoginLinkTTL: *loginLinkTTL,
+	})
 
 	// Routeur principal
 	mux := http.NewServeMux()
@@ -36,6 +220,10 @@ func main() {
 	mux.Handle("/admin/", srv.Mux)
 	mux.Handle("/reservations", srv.Mux) 
 	mux.Handle("/reservations/", srv.Mux) 
+	mux.Handle("/holds", srv.Mux)
+	mux.Handle("/holds/", srv.Mux)
+	mux.Handle("/availability", srv.Mux)
+	mux.Handle("/waitlist/", srv.Mux)
 
 	// Serveur avec timeouts
 	server := &http.Server{
@@ -46,6 +234,41 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	log.Println("Server listening on :8080")
-	log.Fatal(server.ListenAndServe())
-}
\ No newline at end of file
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server listening on :8080 (store: %s)", *storeKind)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		log.Fatal(err)
+	case <-ctx.Done():
+	}
+	stop() // un second signal interrompt immédiatement le processus
+	log.Println("Shutting down...")
+
+	// 1. Plus de nouvelles connexions, les requêtes en cours se terminent
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown: %v", err)
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("server: %v", err)
+	}
+
+	// 2. Fin des rappels et du nettoyage des places retenues (ctx est annulé)
+	background.Wait()
+
+	// 3. Envoi des emails encore en file, plus aucun événement ne pouvant arriver
+	notifier.Close()
+
+	// 4. Fermeture du stockage (base SQLite)
+	if c, ok := repo.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			log.Printf("close store: %v", err)
+		}
+	}
+	log.Println("Server stopped")
+}
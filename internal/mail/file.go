@@ -0,0 +1,56 @@
+package mail
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileMailer n'envoie rien : il écrit chaque message dans un fichier .eml
+// du dossier Dir, ou sur Out si Dir est vide. Pratique en développement
+// local (le lien de connexion s'affiche dans la console) et dans les tests.
+type FileMailer struct {
+	Dir  string
+	Out  io.Writer
+	From string
+
+	mu sync.Mutex
+}
+
+// NewFileMailer écrit les messages dans dir ("" = sortie standard).
+func NewFileMailer(dir string) *FileMailer {
+	return &FileMailer{Dir: dir, Out: os.Stdout, From: "no-reply@localhost"}
+}
+
+// Send enregistre msg.
+func (m *FileMailer) Send(msg Message) error {
+	for _, v := range []string{msg.To, msg.Subject} {
+		if err := validHeader(v); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	data := format(m.From, msg, now)
+
+	if m.Dir == "" {
+		_, err := fmt.Fprintf(m.Out, "----- mail -----\n%s\n----------------\n",
+			strings.ReplaceAll(string(data), "\r\n", "\n"))
+		return err
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	// Nom unique et triable : <horodatage>_<destinataire>.eml
+	name := fmt.Sprintf("%d_%s.eml", now.UnixNano(), strings.NewReplacer("/", "_", "\\", "_").Replace(msg.To))
+	return os.WriteFile(filepath.Join(m.Dir, name), data, 0o600)
+}
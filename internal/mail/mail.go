@@ -0,0 +1,54 @@
+// Package mail envoie les emails de l'application (liens de connexion...).
+//
+// Le reste du code ne dépend que de l'interface Mailer : SMTPMailer en
+// production, FileMailer en développement local et pour les tests.
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// Message = email en texte brut.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer envoie un message.
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// format construit le message au format RFC 5322 (en-têtes + corps),
+// avec des fins de ligne CRLF et un sujet encodé pour les accents.
+func format(from string, msg Message, now time.Time) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	return buf.Bytes()
+}
+
+// validHeader refuse les retours à la ligne dans une valeur d'en-tête
+// (sinon un destinataire forgé pourrait injecter d'autres en-têtes).
+func validHeader(v string) error {
+	if strings.ContainsAny(v, "\r\n") {
+		return fmt.Errorf("mail: invalid header value %q", v)
+	}
+	return nil
+}
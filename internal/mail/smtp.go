@@ -0,0 +1,40 @@
+package mail
+
+import (
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// SMTPMailer envoie les messages via un serveur SMTP.
+// • Addr = "hôte:port" du serveur (ex : "smtp.example.com:587")
+// • From = adresse d'expédition
+// • Username / Password = identifiants (authentification PLAIN), facultatifs
+//
+// smtp.SendMail passe en STARTTLS si le serveur le propose.
+type SMTPMailer struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+// Send envoie msg au destinataire msg.To.
+func (m *SMTPMailer) Send(msg Message) error {
+	for _, v := range []string{m.From, msg.To, msg.Subject} {
+		if err := validHeader(v); err != nil {
+			return err
+		}
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		host, _, err := net.SplitHostPort(m.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+
+	return smtp.SendMail(m.Addr, auth, m.From, []string{msg.To}, format(m.From, msg, time.Now()))
+}
@@ -0,0 +1,225 @@
+// Package notify envoie les emails liés aux réservations (confirmation,
+// annulation, changement d'horaire, déplacement, rappels, place obtenue
+// depuis la liste d'attente).
+//
+// Le BookingService publie des services.Event ; le Notifier les transforme
+// en emails à partir de modèles français ou anglais, puis les envoie en
+// arrière-plan via un mail.Mailer, avec plusieurs tentatives en cas d'échec.
+// La réponse HTTP n'attend donc jamais le serveur SMTP.
+package notify
+
+import (
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"gestionsvc/internal/mail"
+	"gestionsvc/internal/services"
+)
+
+// Config regroupe les paramètres du Notifier.
+// • Sender = envoi réel des emails (SMTP, fichiers...)
+// • BaseURL = adresse publique du front (liens d'annulation)
+// • Lang = langue des emails : "fr" (défaut) ou "en"
+// • MaxAttempts = nombre d'essais par email avant abandon (défaut 5)
+// • RetryDelay = attente avant le 2e essai, doublée à chaque échec (défaut 2s)
+type Config struct {
+	Sender      mail.Mailer
+	BaseURL     string
+	Lang        string
+	MaxAttempts int
+	RetryDelay  time.Duration
+	Location    *time.Location // fuseau des dates sans fuseau connu (UTC si nil)
+}
+
+// job = email en attente d'envoi.
+type job struct {
+	msg     mail.Message
+	attempt int // nombre d'essais déjà faits
+}
+
+// Notifier implémente services.Notifier.
+//
+// Les emails sont placés dans une file (sans limite de taille) et envoyés
+// un par un par une goroutine ; un échec est replanifié plus tard sans
+// bloquer les emails suivants.
+type Notifier struct {
+	cfg Config
+
+	mu     sync.Mutex
+	queue  []job
+	closed bool // plus aucun email accepté
+	wake   chan struct{}
+	done   chan struct{}
+}
+
+// New crée un Notifier et démarre sa goroutine d'envoi.
+func New(cfg Config) *Notifier {
+	if cfg.Lang != LangEN {
+		cfg.Lang = LangFR
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = 2 * time.Second
+	}
+
+	n := &Notifier{
+		cfg:  cfg,
+		wake: make(chan struct{}, 1),
+		done: make(chan struct{}),
+	}
+	go n.run()
+
+	return n
+}
+
+// Notify prépare l'email correspondant à l'événement et le met en file.
+// Ne bloque jamais.
+func (n *Notifier) Notify(e services.Event) {
+	msg, err := n.message(e)
+	if err != nil {
+		log.Printf("notify: %s for %s: %v", e.Type, e.Reservation.ID, err)
+		return
+	}
+
+	n.push(job{msg: msg})
+}
+
+// Close arrête d'accepter des emails, envoie ceux déjà en file
+// puis rend la main. Les essais replanifiés non encore échus sont abandonnés.
+func (n *Notifier) Close() {
+	n.mu.Lock()
+	n.closed = true
+	n.mu.Unlock()
+
+	n.signal()
+	<-n.done
+}
+
+// location renvoie le fuseau dans lequel afficher les dates d'un
+// événement : celui du créneau, à défaut celui du service, sinon celui
+// de la configuration.
+func (n *Notifier) location(e services.Event) *time.Location {
+	for _, name := range []string{e.Slot.TimeZone, e.Service.TimeZone} {
+		if name == "" {
+			continue
+		}
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	if n.cfg.Location != nil {
+		return n.cfg.Location
+	}
+	return time.UTC
+}
+
+// message construit l'email d'un événement.
+func (n *Notifier) message(e services.Event) (mail.Message, error) {
+	lang := n.cfg.Lang
+	loc := n.location(e)
+	data := templateData{
+		Service:     e.Service.Name,
+		Datetime:    formatDate(lang, e.Slot.Datetime, loc),
+		Reservation: e.Reservation.ID,
+		CancelURL:   n.cancelURL(e.Reservation.ID),
+	}
+	if !e.PreviousDatetime.IsZero() {
+		data.Previous = formatDate(lang, e.PreviousDatetime, loc)
+	}
+	if e.ReminderBefore > 0 {
+		data.Before = formatOffset(lang, e.ReminderBefore)
+	}
+
+	subject, body, err := render(lang, e.Type, data)
+	if err != nil {
+		return mail.Message{}, err
+	}
+
+	return mail.Message{To: e.Reservation.UserEmail, Subject: subject, Body: body}, nil
+}
+
+// cancelURL = page d'accueil avec la réservation pré-remplie dans le
+// formulaire d'annulation (la connexion reste nécessaire pour annuler).
+func (n *Notifier) cancelURL(id services.ID) string {
+	return strings.TrimRight(n.cfg.BaseURL, "/") + "/?cancel=" + url.QueryEscape(string(id))
+}
+
+// push ajoute un job à la file et réveille la goroutine d'envoi.
+func (n *Notifier) push(j job) {
+	n.mu.Lock()
+	if n.closed {
+		n.mu.Unlock()
+		log.Printf("notify: closed, dropping mail to %s (%q)", j.msg.To, j.msg.Subject)
+		return
+	}
+	n.queue = append(n.queue, j)
+	n.mu.Unlock()
+
+	n.signal()
+}
+
+// signal réveille la goroutine d'envoi sans jamais bloquer.
+func (n *Notifier) signal() {
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// pop retire le prochain job ; ok = false quand la file est vide.
+// stop = true quand le Notifier est fermé et qu'il n'y a plus rien à envoyer.
+func (n *Notifier) pop() (j job, ok, stop bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.queue) == 0 {
+		return job{}, false, n.closed
+	}
+
+	j = n.queue[0]
+	n.queue = n.queue[1:]
+	return j, true, false
+}
+
+// run envoie les emails de la file jusqu'à Close.
+func (n *Notifier) run() {
+	defer close(n.done)
+
+	for {
+		j, ok, stop := n.pop()
+		if stop {
+			return
+		}
+		if !ok {
+			<-n.wake
+			continue
+		}
+
+		n.deliver(j)
+	}
+}
+
+// deliver tente un envoi et replanifie le job en cas d'échec.
+func (n *Notifier) deliver(j job) {
+	err := n.cfg.Sender.Send(j.msg)
+	if err == nil {
+		return
+	}
+
+	j.attempt++
+	if j.attempt >= n.cfg.MaxAttempts {
+		log.Printf("notify: giving up on mail to %s (%q) after %d attempts: %v",
+			j.msg.To, j.msg.Subject, j.attempt, err)
+		return
+	}
+
+	delay := n.cfg.RetryDelay << (j.attempt - 1)
+	log.Printf("notify: mail to %s failed (attempt %d), retrying in %s: %v", j.msg.To, j.attempt, delay, err)
+
+	time.AfterFunc(delay, func() { n.push(j) })
+}
@@ -0,0 +1,282 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"gestionsvc/internal/services"
+)
+
+//
+// ---------- Modèles d'emails (français / anglais) ----------
+//
+
+// Langues disponibles pour les emails.
+const (
+	LangFR = "fr"
+	LangEN = "en"
+)
+
+// mailTemplate = sujet + corps d'un email, au format text/template.
+type mailTemplate struct {
+	Subject string
+	Body    string
+}
+
+// templateData = données disponibles dans les modèles.
+type templateData struct {
+	Service     string // nom du service
+	Datetime    string // date du créneau, déjà formatée dans la langue
+	Previous    string // ancienne date (slot.changed, reservation.rescheduled)
+	Before      string // délai avant le créneau, ex : "24 heures" (reservation.reminder)
+	Reservation services.ID
+	CancelURL   string // lien pour annuler depuis le front
+}
+
+// templates[langue][type d'événement]
+var templates = map[string]map[services.EventType]mailTemplate{
+	LangFR: {
+		services.EventReservationCreated: {
+			Subject: "Réservation confirmée : {{.Service}}",
+			Body: `Bonjour,
+
+Votre réservation est confirmée.
+
+  Service : {{.Service}}
+  Date    : {{.Datetime}}
+  Réf.    : {{.Reservation}}
+
+Pour annuler : {{.CancelURL}}
+
+À bientôt !
+`,
+		},
+		services.EventReservationCancelled: {
+			Subject: "Réservation annulée : {{.Service}}",
+			Body: `Bonjour,
+
+Votre réservation a été annulée.
+
+  Service : {{.Service}}
+  Date    : {{.Datetime}}
+  Réf.    : {{.Reservation}}
+
+Vous pouvez réserver un autre créneau à tout moment.
+`,
+		},
+		services.EventSlotChanged: {
+			Subject: "Changement d'horaire : {{.Service}}",
+			Body: `Bonjour,
+
+L'horaire de votre réservation a changé.
+
+  Service       : {{.Service}}
+  Ancienne date : {{.Previous}}
+  Nouvelle date : {{.Datetime}}
+  Réf.          : {{.Reservation}}
+
+Si le nouvel horaire ne vous convient pas, vous pouvez annuler : {{.CancelURL}}
+`,
+		},
+		services.EventReminder: {
+			Subject: "Rappel : {{.Service}} dans {{.Before}}",
+			Body: `Bonjour,
+
+Petit rappel : votre rendez-vous a lieu dans {{.Before}}.
+
+  Service : {{.Service}}
+  Date    : {{.Datetime}}
+  Réf.    : {{.Reservation}}
+
+Empêchement ? Annulez pour libérer la place : {{.CancelURL}}
+`,
+		},
+		services.EventWaitlistPromoted: {
+			Subject: "Une place s'est libérée : {{.Service}}",
+			Body: `Bonjour,
+
+Une place s'est libérée : vous quittez la liste d'attente et votre
+réservation est confirmée.
+
+  Service : {{.Service}}
+  Date    : {{.Datetime}}
+  Réf.    : {{.Reservation}}
+
+Si vous n'êtes plus disponible, annulez pour laisser la place : {{.CancelURL}}
+`,
+		},
+		services.EventReservationRescheduled: {
+			Subject: "Réservation déplacée : {{.Service}}",
+			Body: `Bonjour,
+
+Votre réservation a bien été déplacée.
+
+  Service       : {{.Service}}
+  Ancienne date : {{.Previous}}
+  Nouvelle date : {{.Datetime}}
+  Réf.          : {{.Reservation}}
+
+Pour annuler : {{.CancelURL}}
+`,
+		},
+	},
+	LangEN: {
+		services.EventReservationCreated: {
+			Subject: "Booking confirmed: {{.Service}}",
+			Body: `Hello,
+
+Your booking is confirmed.
+
+  Service : {{.Service}}
+  Date    : {{.Datetime}}
+  Ref.    : {{.Reservation}}
+
+To cancel: {{.CancelURL}}
+
+See you soon!
+`,
+		},
+		services.EventReservationCancelled: {
+			Subject: "Booking cancelled: {{.Service}}",
+			Body: `Hello,
+
+Your booking has been cancelled.
+
+  Service : {{.Service}}
+  Date    : {{.Datetime}}
+  Ref.    : {{.Reservation}}
+
+You can book another slot at any time.
+`,
+		},
+		services.EventSlotChanged: {
+			Subject: "Time change: {{.Service}}",
+			Body: `Hello,
+
+The time of your booking has changed.
+
+  Service  : {{.Service}}
+  Old date : {{.Previous}}
+  New date : {{.Datetime}}
+  Ref.     : {{.Reservation}}
+
+If the new time does not suit you, you can cancel: {{.CancelURL}}
+`,
+		},
+		services.EventReminder: {
+			Subject: "Reminder: {{.Service}} in {{.Before}}",
+			Body: `Hello,
+
+A quick reminder: your appointment is in {{.Before}}.
+
+  Service : {{.Service}}
+  Date    : {{.Datetime}}
+  Ref.    : {{.Reservation}}
+
+Can't make it? Please cancel to free the slot: {{.CancelURL}}
+`,
+		},
+		services.EventWaitlistPromoted: {
+			Subject: "A seat opened up: {{.Service}}",
+			Body: `Hello,
+
+A seat opened up: you have left the waitlist and your booking
+is confirmed.
+
+  Service : {{.Service}}
+  Date    : {{.Datetime}}
+  Ref.    : {{.Reservation}}
+
+If you are no longer available, please cancel to free the seat: {{.CancelURL}}
+`,
+		},
+		services.EventReservationRescheduled: {
+			Subject: "Booking moved: {{.Service}}",
+			Body: `Hello,
+
+Your booking has been moved.
+
+  Service  : {{.Service}}
+  Old date : {{.Previous}}
+  New date : {{.Datetime}}
+  Ref.     : {{.Reservation}}
+
+To cancel: {{.CancelURL}}
+`,
+		},
+	},
+}
+
+// parsed = modèles compilés au démarrage (une erreur de syntaxe fait planter tout de suite).
+var parsed = func() map[string]map[services.EventType][2]*template.Template {
+	out := make(map[string]map[services.EventType][2]*template.Template)
+	for lang, byType := range templates {
+		out[lang] = make(map[services.EventType][2]*template.Template)
+		for t, mt := range byType {
+			name := lang + "/" + string(t)
+			out[lang][t] = [2]*template.Template{
+				template.Must(template.New(name + "/subject").Parse(mt.Subject)),
+				template.Must(template.New(name + "/body").Parse(mt.Body)),
+			}
+		}
+	}
+	return out
+}()
+
+// render produit le sujet et le corps d'un email.
+func render(lang string, t services.EventType, data templateData) (subject, body string, err error) {
+	tpl, ok := parsed[lang][t]
+	if !ok {
+		return "", "", fmt.Errorf("notify: no template for %s/%s", lang, t)
+	}
+
+	var sb, bb bytes.Buffer
+	if err := tpl[0].Execute(&sb, data); err != nil {
+		return "", "", err
+	}
+	if err := tpl[1].Execute(&bb, data); err != nil {
+		return "", "", err
+	}
+
+	return strings.TrimSpace(sb.String()), bb.String(), nil
+}
+
+// formatOffset affiche un délai en heures ou en minutes.
+// Ex : "24 heures", "1 heure", "30 minutes" / "24 hours", "1 hour"
+func formatOffset(lang string, d time.Duration) string {
+	n, unitFR, unitEN := int(d.Minutes()), "minute", "minute"
+	if d >= time.Hour && d%time.Hour == 0 {
+		n, unitFR, unitEN = int(d.Hours()), "heure", "hour"
+	}
+
+	unit := unitEN
+	if lang == LangFR {
+		unit = unitFR
+	}
+	if n > 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", n, unit)
+}
+
+// Noms français des jours et des mois (le package time ne les traduit pas).
+var (
+	frDays   = [...]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"}
+	frMonths = [...]string{"janvier", "février", "mars", "avril", "mai", "juin",
+		"juillet", "août", "septembre", "octobre", "novembre", "décembre"}
+)
+
+// formatDate affiche une date lisible dans la langue de l'email, en heure
+// locale du fuseau loc.
+// Ex : "lundi 4 janvier 2027 à 10:00 (Europe/Paris)" / "Monday, January 4, 2027 at 10:00 (Europe/Paris)"
+func formatDate(lang string, t time.Time, loc *time.Location) string {
+	t = t.In(loc)
+	if lang == LangFR {
+		return fmt.Sprintf("%s %d %s %d à %s (%s)",
+			frDays[t.Weekday()], t.Day(), frMonths[t.Month()-1], t.Year(), t.Format("15:04"), loc)
+	}
+	return t.Format("Monday, January 2, 2006 at 15:04") + " (" + loc.String() + ")"
+}
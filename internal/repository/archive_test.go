@@ -0,0 +1,148 @@
+package repository_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gestionsvc/internal/repository"
+	"gestionsvc/internal/services"
+)
+
+// forEachStore lance test sur un JSONStore et un SQLStore vides.
+func forEachStore(t *testing.T, test func(t *testing.T, repo services.Repository)) {
+	t.Run("json", func(t *testing.T) {
+		repo, err := repository.NewJSONStore(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		test(t, repo)
+	})
+	t.Run("sql", func(t *testing.T) {
+		repo, err := repository.NewSQLStore(filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer repo.Close()
+		test(t, repo)
+	})
+}
+
+// Supprimer un créneau l'archive : il disparaît des lectures mais ses
+// réservations restent, annulées.
+func TestDeleteSlotArchives(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repo services.Repository) {
+		b := services.NewBookingService(repo)
+		svc, err := b.CreateService(services.Service{Name: "Yoga", Duration: 60})
+		if err != nil {
+			t.Fatal(err)
+		}
+		slot, err := b.AddSlot(svc.ID, "2099-01-05T09:00:00Z", 2, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := b.Book(slot.ID, "alice@example.com", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := b.DeleteSlot(slot.ID, false); !errors.Is(err, services.ErrSlotInUse) {
+			t.Fatalf("delete without cascade: got %v, want ErrSlotInUse", err)
+		}
+		cancelled, err := b.DeleteSlot(slot.ID, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(cancelled) != 1 || cancelled[0].Status != services.StatusCancelled {
+			t.Fatalf("cancelled = %+v, want one cancelled reservation", cancelled)
+		}
+
+		if _, err := repo.GetSlot(slot.ID); !errors.Is(err, services.ErrSlotNotFound) {
+			t.Fatalf("GetSlot after delete: got %v, want ErrSlotNotFound", err)
+		}
+		if list, _ := repo.ListSlotsByService(svc.ID); len(list) != 0 {
+			t.Fatalf("ListSlotsByService after delete: %d slots, want 0", len(list))
+		}
+		if err := repo.DeleteSlot(slot.ID, time.Now()); !errors.Is(err, services.ErrSlotNotFound) {
+			t.Fatalf("second delete: got %v, want ErrSlotNotFound", err)
+		}
+
+		kept, err := repo.GetReservation(res.ID)
+		if err != nil {
+			t.Fatalf("reservation of an archived slot: %v", err)
+		}
+		if kept.Status != services.StatusCancelled || kept.CancelledAt == nil {
+			t.Fatalf("kept reservation = %+v, want cancelled", kept)
+		}
+		mine, err := b.MyReservations("alice@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(mine) != 1 {
+			t.Fatalf("MyReservations = %d, want 1", len(mine))
+		}
+	})
+}
+
+// Supprimer un service archive aussi ses créneaux et vide leurs files
+// d'attente ; l'intégrité du stockage reste correcte.
+func TestDeleteServiceArchives(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repo services.Repository) {
+		b := services.NewBookingService(repo)
+		svc, err := b.CreateService(services.Service{Name: "Yoga", Duration: 60})
+		if err != nil {
+			t.Fatal(err)
+		}
+		slot, err := b.AddSlot(svc.ID, "2099-01-05T09:00:00Z", 1, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := b.Book(slot.ID, "alice@example.com", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := b.BookOrWait(slot.ID, "bob@example.com", 1); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := b.DeleteService(svc.ID, false); !errors.Is(err, services.ErrServiceInUse) {
+			t.Fatalf("delete without cascade: got %v, want ErrServiceInUse", err)
+		}
+		if _, err := b.DeleteService(svc.ID, true); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := repo.GetService(svc.ID); !errors.Is(err, services.ErrServiceNotFound) {
+			t.Fatalf("GetService after delete: got %v, want ErrServiceNotFound", err)
+		}
+		if list, _ := repo.ListServices(); len(list) != 0 {
+			t.Fatalf("ListServices after delete: %d services, want 0", len(list))
+		}
+		if _, err := repo.GetSlot(slot.ID); !errors.Is(err, services.ErrSlotNotFound) {
+			t.Fatalf("GetSlot after delete: got %v, want ErrSlotNotFound", err)
+		}
+		if _, err := b.AddSlot(svc.ID, "2099-01-06T09:00:00Z", 1, nil); !errors.Is(err, services.ErrServiceNotFound) {
+			t.Fatalf("AddSlot on an archived service: got %v, want ErrServiceNotFound", err)
+		}
+		if wl, err := repo.ListWaitlistBySlot(slot.ID); err != nil || len(wl) != 0 {
+			t.Fatalf("waitlist after delete: %d entries (%v), want 0", len(wl), err)
+		}
+
+		kept, err := repo.GetReservation(res.ID)
+		if err != nil {
+			t.Fatalf("reservation of an archived service: %v", err)
+		}
+		if kept.Status != services.StatusCancelled {
+			t.Fatalf("kept reservation status = %q, want cancelled", kept.Status)
+		}
+
+		report, err := repo.CheckIntegrity(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(report.OrphanSlots) != 0 || len(report.OrphanReservations) != 0 {
+			t.Fatalf("integrity report after archival: %+v", report)
+		}
+	})
+}
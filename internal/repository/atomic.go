@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+//
+// ---------- Écritures atomiques sur disque ----------
+//
+
+// Noms des fichiers de contrôle du JSONStore.
+//
+// • manifest.json = dernière sauvegarde complète (génération + empreintes)
+// • manifest.pending = sauvegarde validée dont les renommages ne sont peut-être
+// pas tous faits (supprimé en fin de save)
+const (
+	manifestFile = "manifest.json"
+	pendingFile  = "manifest.pending"
+	tmpSuffix    = ".tmp"
+)
+
+// manifest décrit une génération de sauvegarde : son numéro et l'empreinte
+// SHA-256 de chaque fichier JSON écrit lors de cette génération.
+type manifest struct {
+	Generation uint64            `json:"generation"`
+	Files      map[string]string `json:"files"`
+}
+
+// checksum renvoie l'empreinte SHA-256 (hexadécimale) d'un contenu.
+func checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeFileSync écrit b dans path puis force l'écriture sur disque (fsync).
+func writeFileSync(path string, b []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// writeFileAtomic remplace path par b de façon atomique :
+// écriture dans un fichier temporaire, fsync, puis renommage.
+func writeFileAtomic(path string, b []byte) error {
+	tmp := path + tmpSuffix
+	if err := writeFileSync(tmp, b); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// syncDir rend durables les renommages effectués dans dir.
+//
+// Windows ne permet pas d'ouvrir un dossier pour le synchroniser :
+// le renommage y est déjà durable, on ne fait donc rien.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+// readManifest lit un manifeste ; ok vaut false si le fichier n'existe pas.
+func readManifest(path string) (m manifest, ok bool, err error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return manifest{}, false, nil
+	}
+	if err != nil {
+		return manifest{}, false, err
+	}
+
+	if err := json.Unmarshal(b, &m); err != nil {
+		return manifest{}, false, err
+	}
+	return m, true, nil
+}
+
+// removeIfExists supprime path en ignorant l'absence du fichier.
+func removeIfExists(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package repository_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"gestionsvc/internal/repository"
+	"gestionsvc/internal/services"
+)
+
+// Réservations simultanées sur un créneau d'une place : une seule doit
+// passer, quel que soit le stockage.
+
+const concurrentBookers = 20
+
+func TestConcurrentBookingJSONStore(t *testing.T) {
+	repo, err := repository.NewJSONStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testNoOverbooking(t, repo)
+}
+
+func TestConcurrentBookingSQLStore(t *testing.T) {
+	repo, err := repository.NewSQLStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	testNoOverbooking(t, repo)
+}
+
+func testNoOverbooking(t *testing.T, repo services.Repository) {
+	t.Helper()
+	b := services.NewBookingService(repo)
+
+	svc, err := b.CreateService(services.Service{Name: "Yoga", Duration: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slot, err := b.AddSlot(svc.ID, "2099-01-05T09:00:00Z", 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, concurrentBookers)
+	)
+	start := make(chan struct{})
+	for i := range concurrentBookers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, errs[i] = b.Book(slot.ID, fmt.Sprintf("user%d@example.com", i), 1)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	var booked, full int
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			booked++
+		case errors.Is(err, services.ErrSlotFull):
+			full++
+		default:
+			t.Errorf("booking %d: unexpected error: %v", i, err)
+		}
+	}
+	if booked != 1 || full != concurrentBookers-1 {
+		t.Fatalf("got %d bookings and %d ErrSlotFull, want 1 and %d", booked, full, concurrentBookers-1)
+	}
+
+	list, err := repo.ListReservationsBySlot(slot.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("stored %d reservations, want 1", len(list))
+	}
+}
@@ -0,0 +1,109 @@
+package repository_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"gestionsvc/internal/services"
+)
+
+// CountSeats donne les mêmes comptes sur les deux stores, y compris au-delà
+// d'un lot SQL (500 créneaux) : réservations annulées et places retenues
+// expirées exclues, créneaux vides absents du résultat.
+func TestCountSeatsAcrossBatches(t *testing.T) {
+	const n = 1201 // trois lots : 500, 500, 201
+	now := time.Date(2099, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	var results []map[int]services.SeatUsage
+	forEachStore(t, func(t *testing.T, repo services.Repository) {
+		svc, err := repo.CreateService(services.Service{Name: "Yoga", Duration: 30})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// ids[i] = créneau i ; want est indexé par i (les IDs diffèrent d'un store à l'autre)
+		ids := make([]services.ID, n)
+		want := map[int]services.SeatUsage{}
+		err = repo.WithTx(func(tx services.Repository) error {
+			for i := 0; i < n; i++ {
+				slot, err := tx.AddSlot(services.Slot{
+					ServiceID: svc.ID,
+					Datetime:  now.Add(time.Duration(i) * time.Hour),
+					Capacity:  10,
+				})
+				if err != nil {
+					return err
+				}
+				ids[i] = slot.ID
+
+				var u services.SeatUsage
+				if i%3 == 0 {
+					if _, err := tx.CreateReservation(services.Reservation{
+						SlotID: slot.ID, UserEmail: "alice@example.com", Seats: 1 + i%4,
+						Status: services.StatusConfirmed, CreatedAt: now,
+					}); err != nil {
+						return err
+					}
+					u.Booked += 1 + i%4
+				}
+				if i%5 == 0 {
+					if _, err := tx.CreateReservation(services.Reservation{
+						SlotID: slot.ID, UserEmail: "bob@example.com", Seats: 2,
+						Status: services.StatusCancelled, CreatedAt: now,
+					}); err != nil {
+						return err
+					}
+				}
+				if i%7 == 0 {
+					// valable si i est pair, expirée sinon
+					expires := now.Add(time.Minute)
+					if i%2 == 1 {
+						expires = now.Add(-time.Minute)
+					} else {
+						u.Held += 3
+					}
+					if _, err := tx.CreateHold(services.Hold{
+						SlotID: slot.ID, UserEmail: "carol@example.com", Seats: 3,
+						CreatedAt: now.Add(-10 * time.Minute), ExpiresAt: expires,
+					}); err != nil {
+						return err
+					}
+				}
+				if u != (services.SeatUsage{}) {
+					want[i] = u
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := repo.CountSeats(append(ids, "slt_unknown"), now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byIndex := map[int]services.SeatUsage{}
+		for i, id := range ids {
+			if u, ok := got[id]; ok {
+				byIndex[i] = u
+			}
+		}
+		if len(got) != len(byIndex) {
+			t.Fatalf("CountSeats returned %d entries, %d for known slots", len(got), len(byIndex))
+		}
+		if !reflect.DeepEqual(byIndex, want) {
+			for i := 0; i < n; i++ {
+				if byIndex[i] != want[i] {
+					t.Fatalf("slot %d: got %+v, want %+v", i, byIndex[i], want[i])
+				}
+			}
+		}
+		results = append(results, byIndex)
+	})
+
+	if len(results) == 2 && !reflect.DeepEqual(results[0], results[1]) {
+		t.Fatal("JSON and SQL stores disagree")
+	}
+}
@@ -0,0 +1,148 @@
+package repository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gestionsvc/internal/services"
+)
+
+// holdsFixture crée un service, un créneau de capacity places dans une
+// semaine et un service de réservation dont l'horloge est réglable.
+func holdsFixture(t *testing.T, repo services.Repository, capacity int) (*services.BookingService, services.Slot, *time.Time) {
+	t.Helper()
+	now := time.Date(2099, 1, 5, 9, 0, 0, 0, time.UTC)
+	b := services.NewBookingService(repo, services.WithClock(func() time.Time { return now }))
+
+	svc, err := b.CreateService(services.Service{Name: "Yoga", Duration: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slot, err := b.AddSlot(svc.ID, now.AddDate(0, 0, 7).Format(time.RFC3339), capacity, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, slot, &now
+}
+
+// Une place retenue expirée ne compte plus dans la capacité, même avant
+// le balayage, et ne peut plus être confirmée.
+func TestHoldExpiryFreesSeats(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repo services.Repository) {
+		b, slot, now := holdsFixture(t, repo, 1)
+
+		hold, err := b.HoldSeats(slot.ID, "alice@example.com", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.Book(slot.ID, "bob@example.com", 1); !errors.Is(err, services.ErrSlotFull) {
+			t.Fatalf("Book while held: err = %v, want ErrSlotFull", err)
+		}
+
+		*now = now.Add(services.DefaultHoldTTL)
+		if _, err := b.ConfirmHold(hold.ID, "alice@example.com"); !errors.Is(err, services.ErrHoldExpired) {
+			t.Fatalf("ConfirmHold after expiry: err = %v, want ErrHoldExpired", err)
+		}
+		if _, err := b.Book(slot.ID, "bob@example.com", 1); err != nil {
+			t.Fatalf("Book after expiry: %v", err)
+		}
+	})
+}
+
+// Le balayage des places expirées passe la place au premier de la liste d'attente.
+func TestSweepExpiredHoldsPromotesWaitlist(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repo services.Repository) {
+		b, slot, now := holdsFixture(t, repo, 1)
+
+		if _, err := b.HoldSeats(slot.ID, "alice@example.com", 1); err != nil {
+			t.Fatal(err)
+		}
+		if _, pos, err := b.BookOrWait(slot.ID, "carol@example.com", 1); err != nil || pos.Position != 1 {
+			t.Fatalf("BookOrWait = position %d, %v; want position 1", pos.Position, err)
+		}
+
+		*now = now.Add(services.DefaultHoldTTL)
+		n, err := b.SweepExpiredHolds()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Fatalf("SweepExpiredHolds = %d, want 1", n)
+		}
+
+		list, err := b.MyReservations("carol@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 1 || list[0].SlotID != slot.ID {
+			t.Fatalf("carol's reservations = %+v, want one on %s", list, slot.ID)
+		}
+	})
+}
+
+// Retenir une place sort l'utilisateur de la liste d'attente du créneau.
+func TestHoldLeavesWaitlist(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repo services.Repository) {
+		b, slot, _ := holdsFixture(t, repo, 2)
+
+		if _, err := b.Book(slot.ID, "bob@example.com", 1); err != nil {
+			t.Fatal(err)
+		}
+		// groupe de 2 pour 1 place libre : inscrit en liste d'attente
+		if _, pos, err := b.BookOrWait(slot.ID, "alice@example.com", 2); err != nil || pos.Position != 1 {
+			t.Fatalf("BookOrWait = position %d, %v; want position 1", pos.Position, err)
+		}
+
+		if _, err := b.HoldSeats(slot.ID, "alice@example.com", 1); err != nil {
+			t.Fatal(err)
+		}
+		waiting, err := b.MyWaitlist("alice@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(waiting) != 0 {
+			t.Fatalf("alice's waitlist = %+v, want empty", waiting)
+		}
+	})
+}
+
+// Un inscrit qui retient déjà des places sur le créneau n'est pas promu :
+// le suivant l'est, et l'inscrit garde son rang.
+func TestPromotionSkipsHolders(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repo services.Repository) {
+		b, slot, now := holdsFixture(t, repo, 2)
+
+		bob, err := b.Book(slot.ID, "bob@example.com", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.HoldSeats(slot.ID, "alice@example.com", 1); err != nil {
+			t.Fatal(err)
+		}
+		// inscription antérieure restée en base (données d'avant le correctif)
+		if _, err := repo.AddWaitlistEntry(services.WaitlistEntry{
+			SlotID: slot.ID, UserEmail: "alice@example.com", Seats: 1, CreatedAt: *now,
+		}); err != nil {
+			t.Fatal(err)
+		}
+		*now = now.Add(time.Second)
+		if _, pos, err := b.BookOrWait(slot.ID, "dave@example.com", 1); err != nil || pos.Position != 2 {
+			t.Fatalf("BookOrWait = position %d, %v; want position 2", pos.Position, err)
+		}
+
+		if err := b.Cancel(bob.ID, "bob@example.com"); err != nil {
+			t.Fatal(err)
+		}
+
+		if list, err := b.MyReservations("dave@example.com"); err != nil || len(list) != 1 {
+			t.Fatalf("dave's reservations = %+v, %v; want one", list, err)
+		}
+		if list, err := b.MyReservations("alice@example.com"); err != nil || len(list) != 0 {
+			t.Fatalf("alice's reservations = %+v, %v; want none", list, err)
+		}
+		if waiting, err := b.MyWaitlist("alice@example.com"); err != nil || len(waiting) != 1 {
+			t.Fatalf("alice's waitlist = %+v, %v; want still one entry", waiting, err)
+		}
+	})
+}
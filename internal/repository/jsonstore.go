@@ -2,11 +2,13 @@ package repository
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"gestionsvc/internal/services"
@@ -22,20 +24,42 @@ import (
 // Elle agit comme une "base de données" chargée en mémoire,
 // et sera régulièrement sauvegardée sur disque.
 type jsonDB struct {
-	Services     []services.Service     `json:"services"`
-	Slots        []services.Slot        `json:"slots"`
-	Reservations []services.Reservation `json:"reservations"`
+	Services     []services.Service       `json:"services"`
+	Slots        []services.Slot          `json:"slots"`
+	Reservations []services.Reservation   `json:"reservations"`
+	Users        []services.User          `json:"users"`
+	Sessions     []services.Session       `json:"sessions"`
+	LoginLinks   []services.LoginLink     `json:"loginLinks"`
+	Reminders    []services.Reminder      `json:"reminders"`
+	Waitlist     []services.WaitlistEntry `json:"waitlist"`
+	Holds        []services.Hold          `json:"holds"`
+	Resources    []services.Resource      `json:"resources"`
 }
 
 //
 // ---------- Helpers ----------
 //
 
+// lastID mémorise le dernier timestamp utilisé par newID.
+var lastID atomic.Int64
+
 // newID génère un identifiant unique simple, basé sur
 // un préfixe + un timestamp haute résolution.
 // Exemple : "svc_1731965329823345000"
+//
+// Si deux appels concurrents tombent sur la même nanoseconde,
+// le second prend la valeur suivante : les IDs restent uniques.
 func newID(prefix string) services.ID {
-	return services.ID(fmt.Sprintf("%s_%d", prefix, time.Now().UnixNano()))
+	for {
+		last := lastID.Load()
+		n := time.Now().UnixNano()
+		if n <= last {
+			n = last + 1
+		}
+		if lastID.CompareAndSwap(last, n) {
+			return services.ID(fmt.Sprintf("%s_%d", prefix, n))
+		}
+	}
 }
 
 //
@@ -45,12 +69,14 @@ func newID(prefix string) services.ID {
 // JSONStore gère la lecture/écriture des fichiers JSON.
 // • root = dossier local contenant les fichiers services.json, slots.json...
 // • db = copie en mémoire des données
-// • mu = évite les accès concurrents
+// • mu = évite les accès concurrents (tenu pendant toute une opération, voir withTx)
+// • generation = numéro de la dernière sauvegarde complète (manifest.json)
 type JSONStore struct {
-	mu     sync.Mutex
-	root   string
-	db     jsonDB
-	loaded bool
+	mu         sync.Mutex
+	root       string
+	db         jsonDB
+	loaded     bool
+	generation uint64
 }
 
 // NewJSONStore crée un store et charge immédiatement les fichiers JSON.
@@ -71,57 +97,84 @@ func (s *JSONStore) dataPath(name string) string {
 // ---------- Chargement / Sauvegarde ----------
 //
 
+// jsonFile associe un fichier du store à la collection qu'il contient.
+type jsonFile struct {
+	name string
+	data any // pointeur vers le slice correspondant de jsonDB
+}
+
+// upgrade complète les données écrites par une version précédente :
+// une réservation (ou inscription, place retenue) sans "seats" compte pour 1 place,
+// une réservation sans "status" est confirmée.
+func (db *jsonDB) upgrade() {
+	for i := range db.Reservations {
+		db.Reservations[i].Seats = db.Reservations[i].SeatCount()
+		db.Reservations[i].Status = db.Reservations[i].Status.OrDefault()
+	}
+	for i := range db.Waitlist {
+		db.Waitlist[i].Seats = db.Waitlist[i].SeatCount()
+	}
+	for i := range db.Holds {
+		db.Holds[i].Seats = db.Holds[i].SeatCount()
+	}
+}
+
+// files liste les fichiers JSON qui composent la base.
+func (db *jsonDB) files() []jsonFile {
+	return []jsonFile{
+		{"services.json", &db.Services},
+		{"slots.json", &db.Slots},
+		{"reservations.json", &db.Reservations},
+		{"users.json", &db.Users},
+		{"sessions.json", &db.Sessions},
+		{"login_links.json", &db.LoginLinks},
+		{"reminders.json", &db.Reminders},
+		{"waitlist.json", &db.Waitlist},
+		{"holds.json", &db.Holds},
+		{"resources.json", &db.Resources},
+	}
+}
+
 // load lit les fichiers JSON du disque et remplit s.db.
 //
+// Avant la lecture, une éventuelle sauvegarde interrompue est terminée
+// ou annulée (voir recoverSave).
 // Si un fichier n'existe pas encore, il est créé automatiquement
 // avec un tableau vide "[]".
+//
+// L'appelant doit détenir s.mu (sauf à la construction du store).
 func (s *JSONStore) load() error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	if err := s.recoverSave(); err != nil {
+		return err
+	}
 
-	// Assure que les fichiers existent
-	ensure := func(name string) error {
-		p := s.dataPath(name)
+	// Relecture après une sauvegarde interrompue : on repart de zéro
+	// pour ne rien garder de l'état en mémoire.
+	s.db = jsonDB{}
+
+	for _, f := range s.db.files() {
+		p := s.dataPath(f.name)
+
+		// Fichier manquant → on crée un fichier vide
 		if _, err := os.Stat(p); os.IsNotExist(err) {
-			// Fichier manquant → on crée un fichier vide
-			return os.WriteFile(p, []byte("[]"), 0o644)
+			if err := os.WriteFile(p, []byte("[]"), 0o644); err != nil {
+				return err
+			}
 		}
-		return nil
-	}
-
-	if err := ensure("services.json"); err != nil {
-		return err
-	}
-	if err := ensure("slots.json"); err != nil {
-		return err
-	}
-	if err := ensure("reservations.json"); err != nil {
-		return err
-	}
 
-	// Fonction utilitaire pour lire un fichier JSON
-	readJSON := func(name string, v any) error {
-		b, err := os.ReadFile(s.dataPath(name))
+		b, err := os.ReadFile(p)
 		if err != nil {
 			return err
 		}
 		if len(b) == 0 {
 			b = []byte("[]")
 		}
-		return json.Unmarshal(b, v)
-	}
-
-	// Lecture des données
-	if err := readJSON("services.json", &s.db.Services); err != nil {
-		return err
-	}
-	if err := readJSON("slots.json", &s.db.Slots); err != nil {
-		return err
-	}
-	if err := readJSON("reservations.json", &s.db.Reservations); err != nil {
-		return err
+		if err := json.Unmarshal(b, f.data); err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
 	}
 
+	s.db.upgrade()
 	s.loaded = true
 	return nil
 }
@@ -129,172 +182,1386 @@ func (s *JSONStore) load() error {
 // save écrit s.db dans les fichiers JSON.
 //
 // C'est ici que la persistance est réellement effectuée à chaque modification.
+// L'écriture se fait en plusieurs étapes pour survivre à un arrêt brutal :
+//  1. chaque fichier est écrit dans "<nom>.tmp" puis fsync ;
+//  2. manifest.pending (génération + empreintes) est écrit atomiquement :
+//     c'est le point de validation de la sauvegarde ;
+//  3. les fichiers temporaires sont renommés vers leur nom définitif ;
+//  4. manifest.pending devient manifest.json.
+//
+// Un arrêt avant l'étape 2 laisse les anciens fichiers intacts,
+// un arrêt après est terminé par load au prochain démarrage.
+//
+// Une erreur à partir de l'étape 2 est traitée de la même façon : la
+// sauvegarde a peut-être été validée, la mémoire ne fait plus foi.
+// Le store est marqué non chargé ; l'accès suivant relit le disque
+// (load → recoverSave) et échoue tant que la reprise échoue.
+//
+// L'appelant doit détenir s.mu.
 func (s *JSONStore) save() error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	m := manifest{
+		Generation: s.generation + 1,
+		Files:      map[string]string{},
+	}
+	files := s.db.files()
 
-	writeJSON := func(name string, v any) error {
-		b, err := json.MarshalIndent(v, "", "  ")
+	for _, f := range files {
+		b, err := json.MarshalIndent(f.data, "", "  ")
 		if err != nil {
 			return err
 		}
-		return os.WriteFile(s.dataPath(name), b, 0o644)
+		if err := writeFileSync(s.dataPath(f.name+tmpSuffix), b); err != nil {
+			return err
+		}
+		m.Files[f.name] = checksum(b)
+	}
+
+	mb, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := s.commit(files, mb); err != nil {
+		s.loaded = false
+		return fmt.Errorf("save of generation %d interrupted: %w", m.Generation, err)
+	}
+
+	s.generation = m.Generation
+	return nil
+}
+
+// commit effectue les étapes 2 à 4 de save : écriture de manifest.pending
+// (mb), renommage des fichiers temporaires, puis du manifeste.
+func (s *JSONStore) commit(files []jsonFile, mb []byte) error {
+	if err := writeFileAtomic(s.dataPath(pendingFile), mb); err != nil {
+		return err
 	}
 
-	if err := writeJSON("services.json", s.db.Services); err != nil {
+	for _, f := range files {
+		if err := os.Rename(s.dataPath(f.name+tmpSuffix), s.dataPath(f.name)); err != nil {
+			return err
+		}
+	}
+	if err := syncDir(s.root); err != nil {
 		return err
 	}
-	if err := writeJSON("slots.json", s.db.Slots); err != nil {
+
+	if err := os.Rename(s.dataPath(pendingFile), s.dataPath(manifestFile)); err != nil {
 		return err
 	}
-	if err := writeJSON("reservations.json", s.db.Reservations); err != nil {
+	return syncDir(s.root)
+}
+
+// recoverSave remet le dossier de données dans un état cohérent
+// après une sauvegarde interrompue.
+//
+//   - manifest.pending présent → la sauvegarde était validée : on termine
+//     les renommages des fichiers temporaires dont l'empreinte correspond ;
+//   - sinon → les fichiers temporaires restants sont abandonnés,
+//     les anciens fichiers sont toujours complets.
+//
+// Enfin, la génération courante est relue depuis manifest.json.
+func (s *JSONStore) recoverSave() error {
+	pending, ok, err := readManifest(s.dataPath(pendingFile))
+	if err != nil {
+		return fmt.Errorf("%s: %w", pendingFile, err)
+	}
+
+	if ok {
+		for name, sum := range pending.Files {
+			final := s.dataPath(name)
+			tmp := final + tmpSuffix
+
+			if b, err := os.ReadFile(tmp); err == nil && checksum(b) == sum {
+				if err := os.Rename(tmp, final); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// Déjà renommé avant l'arrêt ?
+			if b, err := os.ReadFile(final); err == nil && checksum(b) == sum {
+				continue
+			}
+
+			return fmt.Errorf("cannot recover %s from interrupted save (generation %d)", name, pending.Generation)
+		}
+
+		if err := syncDir(s.root); err != nil {
+			return err
+		}
+		if err := os.Rename(s.dataPath(pendingFile), s.dataPath(manifestFile)); err != nil {
+			return err
+		}
+		if err := syncDir(s.root); err != nil {
+			return err
+		}
+
+		log.Printf("jsonstore: interrupted save recovered (generation %d)", pending.Generation)
+	}
+
+	// Restes d'une sauvegarde non validée
+	for _, f := range s.db.files() {
+		if err := removeIfExists(s.dataPath(f.name + tmpSuffix)); err != nil {
+			return err
+		}
+	}
+	if err := removeIfExists(s.dataPath(pendingFile + tmpSuffix)); err != nil {
 		return err
 	}
 
+	current, ok, err := readManifest(s.dataPath(manifestFile))
+	if err != nil {
+		return fmt.Errorf("%s: %w", manifestFile, err)
+	}
+	if ok {
+		s.generation = current.Generation
+	}
+
 	return nil
 }
 
 //
-// ---------- Services ----------
+// ---------- Transactions ----------
 //
 
-// ListServices renvoie la liste des services.
-// On renvoie une copie pour éviter que l’appelant ne modifie directement le slice interne.
-func (s *JSONStore) ListServices() ([]services.Service, error) {
+// jsonTx donne accès aux données en mémoire pendant que s.mu est tenu.
+//
+// Il implémente services.Repository sans verrou ni sauvegarde :
+// c'est withTx qui sauvegarde une seule fois à la fin si une modification
+// a eu lieu, ou restaure la copie de sauvegarde en cas d'erreur.
+type jsonTx struct {
+	db     *jsonDB
+	dirty  bool
+	backup jsonDB
+}
+
+// clone copie les slices de la base pour pouvoir annuler une transaction.
+func (db *jsonDB) clone() jsonDB {
+	return jsonDB{
+		Services:     append([]services.Service(nil), db.Services...),
+		Slots:        append([]services.Slot(nil), db.Slots...),
+		Reservations: append([]services.Reservation(nil), db.Reservations...),
+		Users:        append([]services.User(nil), db.Users...),
+		Sessions:     append([]services.Session(nil), db.Sessions...),
+		LoginLinks:   append([]services.LoginLink(nil), db.LoginLinks...),
+		Reminders:    append([]services.Reminder(nil), db.Reminders...),
+		Waitlist:     append([]services.WaitlistEntry(nil), db.Waitlist...),
+		Holds:        append([]services.Hold(nil), db.Holds...),
+		Resources:    append([]services.Resource(nil), db.Resources...),
+	}
+}
+
+// touch doit être appelé avant chaque modification :
+// la première fois, il garde une copie de l'état initial.
+func (t *jsonTx) touch() {
+	if !t.dirty {
+		t.backup = t.db.clone()
+		t.dirty = true
+	}
+}
+
+// withTx exécute fn sous le verrou du store.
+//
+// Si fn a modifié les données, elles sont sauvegardées une seule fois ;
+// si fn ou la sauvegarde échoue, la mémoire revient à l'état initial
+// (et, si la sauvegarde a pu être validée, sera relue depuis le disque
+// à l'accès suivant : voir save).
+func withTx[T any](s *JSONStore, fn func(tx *jsonTx) (T, error)) (T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var zero T
 	if !s.loaded {
 		if err := s.load(); err != nil {
-			return nil, err
+			return zero, err
+		}
+	}
+
+	tx := &jsonTx{db: &s.db}
+	out, err := fn(tx)
+	if err == nil && tx.dirty {
+		err = s.save()
+	}
+	if err != nil {
+		if tx.dirty {
+			s.db = tx.backup
 		}
+		return zero, err
 	}
-	return append([]services.Service(nil), s.db.Services...), nil
+
+	return out, nil
+}
+
+// WithTx exécute fn de manière atomique : aucune autre opération du store
+// ne peut s'intercaler, et toutes les modifications sont annulées si fn
+// renvoie une erreur.
+func (s *JSONStore) WithTx(fn func(tx services.Repository) error) error {
+	_, err := withTx(s, func(tx *jsonTx) (struct{}, error) {
+		return struct{}{}, fn(tx)
+	})
+	return err
+}
+
+// WithTx dans une transaction déjà ouverte : on réutilise la même.
+func (t *jsonTx) WithTx(fn func(tx services.Repository) error) error {
+	return fn(t)
+}
+
+//
+// ---------- Services ----------
+//
+
+// ListServices renvoie la liste des services.
+func (s *JSONStore) ListServices() ([]services.Service, error) {
+	return withTx(s, (*jsonTx).ListServices)
 }
 
 // CreateService ajoute un nouveau service dans le store.
 func (s *JSONStore) CreateService(svc services.Service) (services.Service, error) {
+	return withTx(s, func(tx *jsonTx) (services.Service, error) {
+		return tx.CreateService(svc)
+	})
+}
+
+// GetService retourne un service selon son ID.
+func (s *JSONStore) GetService(serviceID services.ID) (services.Service, error) {
+	return withTx(s, func(tx *jsonTx) (services.Service, error) {
+		return tx.GetService(serviceID)
+	})
+}
+
+// UpdateService remplace un service existant.
+func (s *JSONStore) UpdateService(svc services.Service) (services.Service, error) {
+	return withTx(s, func(tx *jsonTx) (services.Service, error) {
+		return tx.UpdateService(svc)
+	})
+}
+
+// DeleteService archive un service et ses slots.
+func (s *JSONStore) DeleteService(serviceID services.ID, at time.Time) error {
+	_, err := withTx(s, func(tx *jsonTx) (struct{}, error) {
+		return struct{}{}, tx.DeleteService(serviceID, at)
+	})
+	return err
+}
+
+// ListServices renvoie une copie des services non archivés, pour éviter que
+// l’appelant ne modifie directement le slice interne.
+func (t *jsonTx) ListServices() ([]services.Service, error) {
+	var out []services.Service
+	for _, svc := range t.db.Services {
+		if svc.DeletedAt == nil {
+			out = append(out, svc)
+		}
+	}
+	return out, nil
+}
+
+// CreateService ajoute un nouveau service.
+func (t *jsonTx) CreateService(svc services.Service) (services.Service, error) {
 	if svc.ID == "" {
 		svc.ID = newID("svc")
 	}
 
-	s.db.Services = append(s.db.Services, svc)
-
-	if err := s.save(); err != nil {
-		return services.Service{}, err
-	}
+	t.touch()
+	t.db.Services = append(t.db.Services, svc)
 
 	return svc, nil
 }
 
-//
-// ---------- Slots ----------
-//
+// GetService retourne un service (non archivé) selon son ID.
+func (t *jsonTx) GetService(serviceID services.ID) (services.Service, error) {
+	for _, svc := range t.db.Services {
+		if svc.ID == serviceID && svc.DeletedAt == nil {
+			return svc, nil
+		}
+	}
+	return services.Service{}, services.ErrServiceNotFound
+}
 
-// AddSlot ajoute un créneau horaire (slot) à la liste.
-func (s *JSONStore) AddSlot(slot services.Slot) (services.Slot, error) {
-	if slot.ID == "" {
-		slot.ID = newID("slt")
+// UpdateService remplace un service existant (non archivé).
+func (t *jsonTx) UpdateService(svc services.Service) (services.Service, error) {
+	for i := range t.db.Services {
+		if t.db.Services[i].ID == svc.ID && t.db.Services[i].DeletedAt == nil {
+			t.touch()
+			t.db.Services[i] = svc
+			return svc, nil
+		}
+	}
+	return services.Service{}, services.ErrServiceNotFound
+}
+
+// DeleteService archive un service et ses slots ; leurs réservations
+// sont conservées (historique).
+func (t *jsonTx) DeleteService(serviceID services.ID, at time.Time) error {
+	if _, err := t.GetService(serviceID); err != nil {
+		return err
 	}
 
-	s.db.Slots = append(s.db.Slots, slot)
+	t.touch()
 
-	if err := s.save(); err != nil {
-		return services.Slot{}, err
+	for i := range t.db.Services {
+		if t.db.Services[i].ID == serviceID {
+			t.db.Services[i].DeletedAt = &at
+		}
 	}
 
-	return slot, nil
+	removed := map[services.ID]bool{}
+	for _, sl := range t.db.Slots {
+		if sl.ServiceID == serviceID && sl.DeletedAt == nil {
+			removed[sl.ID] = true
+		}
+	}
+	t.archiveSlots(removed, at)
+	return nil
 }
 
-// ListSlotsByService retourne tous les créneaux liés à un service donné.
-func (s *JSONStore) ListSlotsByService(serviceID services.ID) ([]services.Slot, error) {
-	var out []services.Slot
-	for _, sl := range s.db.Slots {
-		if sl.ServiceID == serviceID {
-			out = append(out, sl)
+// archiveSlots archive les créneaux supprimés à la date at : leurs
+// réservations restent, leurs listes d'attente, places retenues, ressources
+// et rappels envoyés sont supprimés (le JSON n'a pas de clés étrangères).
+func (t *jsonTx) archiveSlots(removed map[services.ID]bool, at time.Time) {
+	for i := range t.db.Slots {
+		if removed[t.db.Slots[i].ID] {
+			t.db.Slots[i].DeletedAt = &at
+			t.db.Slots[i].Resources = nil
 		}
 	}
-	return out, nil
-}
 
-// GetSlot retourne un slot selon son ID.
-func (s *JSONStore) GetSlot(slotID services.ID) (services.Slot, error) {
-	for _, sl := range s.db.Slots {
-		if sl.ID == slotID {
-			return sl, nil
+	resIDs := map[services.ID]bool{}
+	for _, r := range t.db.Reservations {
+		if removed[r.SlotID] {
+			resIDs[r.ID] = true
+		}
+	}
+	t.dropReminders(resIDs)
+
+	var wl []services.WaitlistEntry
+	for _, w := range t.db.Waitlist {
+		if !removed[w.SlotID] {
+			wl = append(wl, w)
 		}
 	}
-	return services.Slot{}, errors.New("slot not found")
+
+	var holds []services.Hold
+	for _, h := range t.db.Holds {
+		if !removed[h.SlotID] {
+			holds = append(holds, h)
+		}
+	}
+
+	t.db.Waitlist = wl
+	t.db.Holds = holds
 }
 
 //
-// ---------- Reservations ----------
+// ---------- Slots ----------
 //
 
-// CreateReservation enregistre une réservation.
-func (s *JSONStore) CreateReservation(r services.Reservation) (services.Reservation, error) {
-	if r.ID == "" {
-		r.ID = newID("res")
-	}
+// AddSlot ajoute un créneau horaire (slot) à la liste.
+func (s *JSONStore) AddSlot(slot services.Slot) (services.Slot, error) {
+	return withTx(s, func(tx *jsonTx) (services.Slot, error) {
+		return tx.AddSlot(slot)
+	})
+}
+
+// ListSlotsByService retourne tous les créneaux liés à un service donné.
+func (s *JSONStore) ListSlotsByService(serviceID services.ID) ([]services.Slot, error) {
+	return withTx(s, func(tx *jsonTx) ([]services.Slot, error) {
+		return tx.ListSlotsByService(serviceID)
+	})
+}
 
-	s.db.Reservations = append(s.db.Reservations, r)
+// ListSlotsByResource retourne les créneaux qui mobilisent une ressource.
+func (s *JSONStore) ListSlotsByResource(resourceID services.ID) ([]services.Slot, error) {
+	return withTx(s, func(tx *jsonTx) ([]services.Slot, error) {
+		return tx.ListSlotsByResource(resourceID)
+	})
+}
 
-	if err := s.save(); err != nil {
-		return services.Reservation{}, err
+// GetSlot retourne un slot selon son ID.
+func (s *JSONStore) GetSlot(slotID services.ID) (services.Slot, error) {
+	return withTx(s, func(tx *jsonTx) (services.Slot, error) {
+		return tx.GetSlot(slotID)
+	})
+}
+
+// UpdateSlot remplace un créneau existant.
+func (s *JSONStore) UpdateSlot(slot services.Slot) (services.Slot, error) {
+	return withTx(s, func(tx *jsonTx) (services.Slot, error) {
+		return tx.UpdateSlot(slot)
+	})
+}
+
+// DeleteSlot archive un créneau.
+func (s *JSONStore) DeleteSlot(slotID services.ID, at time.Time) error {
+	_, err := withTx(s, func(tx *jsonTx) (struct{}, error) {
+		return struct{}{}, tx.DeleteSlot(slotID, at)
+	})
+	return err
+}
+
+// AddSlot ajoute un créneau horaire (slot) à la liste.
+// Le service et les ressources référencés doivent exister.
+func (t *jsonTx) AddSlot(slot services.Slot) (services.Slot, error) {
+	if _, err := t.GetService(slot.ServiceID); err != nil {
+		return services.Slot{}, err
+	}
+	if err := t.checkSlotResources(slot); err != nil {
+		return services.Slot{}, err
+	}
+	if slot.ID == "" {
+		slot.ID = newID("slt")
 	}
 
-	return r, nil
+	t.touch()
+	t.db.Slots = append(t.db.Slots, slot)
+
+	return slot, nil
 }
 
-// ListReservationsByEmail recherche toutes les réservations d’un utilisateur.
-func (s *JSONStore) ListReservationsByEmail(email string) ([]services.Reservation, error) {
-	var out []services.Reservation
-	for _, r := range s.db.Reservations {
-		if r.UserEmail == email {
-			out = append(out, r)
+// ListSlotsByService retourne tous les créneaux liés à un service donné.
+func (t *jsonTx) ListSlotsByService(serviceID services.ID) ([]services.Slot, error) {
+	var out []services.Slot
+	for _, sl := range t.db.Slots {
+		if sl.ServiceID == serviceID && sl.DeletedAt == nil {
+			out = append(out, sl)
 		}
 	}
 	return out, nil
 }
 
-// ListReservationsBySlot retourne les réservations d’un créneau donné.
-func (s *JSONStore) ListReservationsBySlot(slotID services.ID) ([]services.Reservation, error) {
-	var out []services.Reservation
-	for _, r := range s.db.Reservations {
-		if r.SlotID == slotID {
-			out = append(out, r)
+// ListSlotsByResource retourne les créneaux qui mobilisent une ressource.
+func (t *jsonTx) ListSlotsByResource(resourceID services.ID) ([]services.Slot, error) {
+	var out []services.Slot
+	for _, sl := range t.db.Slots {
+		if sl.DeletedAt != nil {
+			continue
+		}
+		for _, id := range sl.Resources {
+			if id == resourceID {
+				out = append(out, sl)
+				break
+			}
 		}
 	}
 	return out, nil
 }
 
-// GetReservation récupère une réservation par ID.
-func (s *JSONStore) GetReservation(resID services.ID) (services.Reservation, error) {
-	for _, r := range s.db.Reservations {
-		if r.ID == resID {
-			return r, nil
+// GetSlot retourne un slot (non archivé) selon son ID.
+func (t *jsonTx) GetSlot(slotID services.ID) (services.Slot, error) {
+	for _, sl := range t.db.Slots {
+		if sl.ID == slotID && sl.DeletedAt == nil {
+			return sl, nil
 		}
 	}
-	return services.Reservation{}, errors.New("reservation not found")
+	return services.Slot{}, services.ErrSlotNotFound
 }
 
-// DeleteReservation supprime une réservation si elle existe.
-func (s *JSONStore) DeleteReservation(resID services.ID) error {
-	idx := -1
+// UpdateSlot remplace un créneau existant.
+func (t *jsonTx) UpdateSlot(slot services.Slot) (services.Slot, error) {
+	if _, err := t.GetService(slot.ServiceID); err != nil {
+		return services.Slot{}, err
+	}
+	if err := t.checkSlotResources(slot); err != nil {
+		return services.Slot{}, err
+	}
+	for i := range t.db.Slots {
+		if t.db.Slots[i].ID == slot.ID && t.db.Slots[i].DeletedAt == nil {
+			t.touch()
+			t.db.Slots[i] = slot
+			return slot, nil
+		}
+	}
+	return services.Slot{}, services.ErrSlotNotFound
+}
 
-	for i, r := range s.db.Reservations {
-		if r.ID == resID {
-			idx = i
-			break
+// checkSlotResources vérifie que les ressources d'un créneau existent.
+func (t *jsonTx) checkSlotResources(slot services.Slot) error {
+	for _, id := range slot.Resources {
+		if _, err := t.GetResource(id); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	if idx < 0 {
-		return errors.New("reservation not found")
+// DeleteSlot archive un créneau ; ses réservations sont conservées.
+func (t *jsonTx) DeleteSlot(slotID services.ID, at time.Time) error {
+	if _, err := t.GetSlot(slotID); err != nil {
+		return err
 	}
 
-	// Suppression propre du slice
-	s.db.Reservations = append(
-		s.db.Reservations[:idx],
-		s.db.Reservations[idx+1:]...,
-	)
+	t.touch()
+	t.archiveSlots(map[services.ID]bool{slotID: true}, at)
+	return nil
+}
 
-	return s.save()
-}
\ No newline at end of file
+//
+// ---------- Ressources ----------
+//
+
+// ListResources renvoie la liste des ressources.
+func (s *JSONStore) ListResources() ([]services.Resource, error) {
+	return withTx(s, (*jsonTx).ListResources)
+}
+
+// GetResource retourne une ressource selon son ID.
+func (s *JSONStore) GetResource(id services.ID) (services.Resource, error) {
+	return withTx(s, func(tx *jsonTx) (services.Resource, error) {
+		return tx.GetResource(id)
+	})
+}
+
+// CreateResource ajoute une ressource.
+func (s *JSONStore) CreateResource(r services.Resource) (services.Resource, error) {
+	return withTx(s, func(tx *jsonTx) (services.Resource, error) {
+		return tx.CreateResource(r)
+	})
+}
+
+// UpdateResource remplace une ressource existante.
+func (s *JSONStore) UpdateResource(r services.Resource) (services.Resource, error) {
+	return withTx(s, func(tx *jsonTx) (services.Resource, error) {
+		return tx.UpdateResource(r)
+	})
+}
+
+// DeleteResource supprime une ressource.
+func (s *JSONStore) DeleteResource(id services.ID) error {
+	_, err := withTx(s, func(tx *jsonTx) (struct{}, error) {
+		return struct{}{}, tx.DeleteResource(id)
+	})
+	return err
+}
+
+// ListResources renvoie une copie de la liste des ressources.
+func (t *jsonTx) ListResources() ([]services.Resource, error) {
+	return append([]services.Resource(nil), t.db.Resources...), nil
+}
+
+// GetResource retourne une ressource selon son ID.
+func (t *jsonTx) GetResource(id services.ID) (services.Resource, error) {
+	for _, r := range t.db.Resources {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return services.Resource{}, services.ErrResourceNotFound
+}
+
+// CreateResource ajoute une ressource.
+func (t *jsonTx) CreateResource(r services.Resource) (services.Resource, error) {
+	if r.ID == "" {
+		r.ID = newID("rsc")
+	}
+
+	t.touch()
+	t.db.Resources = append(t.db.Resources, r)
+
+	return r, nil
+}
+
+// UpdateResource remplace une ressource existante.
+func (t *jsonTx) UpdateResource(r services.Resource) (services.Resource, error) {
+	for i := range t.db.Resources {
+		if t.db.Resources[i].ID == r.ID {
+			t.touch()
+			t.db.Resources[i] = r
+			return r, nil
+		}
+	}
+	return services.Resource{}, services.ErrResourceNotFound
+}
+
+// DeleteResource supprime une ressource. Les créneaux qui la citent encore
+// sont refusés (équivalent de la clé étrangère de la base SQL).
+func (t *jsonTx) DeleteResource(id services.ID) error {
+	if slots, _ := t.ListSlotsByResource(id); len(slots) > 0 {
+		return services.ErrResourceInUse
+	}
+	for i, r := range t.db.Resources {
+		if r.ID == id {
+			t.touch()
+			t.db.Resources = append(t.db.Resources[:i], t.db.Resources[i+1:]...)
+			return nil
+		}
+	}
+	return services.ErrResourceNotFound
+}
+
+//
+// ---------- Reservations ----------
+//
+
+// CreateReservation enregistre une réservation.
+func (s *JSONStore) CreateReservation(r services.Reservation) (services.Reservation, error) {
+	return withTx(s, func(tx *jsonTx) (services.Reservation, error) {
+		return tx.CreateReservation(r)
+	})
+}
+
+// ListReservationsByEmail recherche toutes les réservations d’un utilisateur.
+func (s *JSONStore) ListReservationsByEmail(email string) ([]services.Reservation, error) {
+	return withTx(s, func(tx *jsonTx) ([]services.Reservation, error) {
+		return tx.ListReservationsByEmail(email)
+	})
+}
+
+// ListReservationsBySlot retourne les réservations d’un créneau donné.
+func (s *JSONStore) ListReservationsBySlot(slotID services.ID) ([]services.Reservation, error) {
+	return withTx(s, func(tx *jsonTx) ([]services.Reservation, error) {
+		return tx.ListReservationsBySlot(slotID)
+	})
+}
+
+// CountSeats compte les places occupées de plusieurs créneaux.
+func (s *JSONStore) CountSeats(slotIDs []services.ID, now time.Time) (map[services.ID]services.SeatUsage, error) {
+	return withTx(s, func(tx *jsonTx) (map[services.ID]services.SeatUsage, error) {
+		return tx.CountSeats(slotIDs, now)
+	})
+}
+
+// GetReservation récupère une réservation par ID.
+func (s *JSONStore) GetReservation(resID services.ID) (services.Reservation, error) {
+	return withTx(s, func(tx *jsonTx) (services.Reservation, error) {
+		return tx.GetReservation(resID)
+	})
+}
+
+// UpdateReservation remplace une réservation existante.
+func (s *JSONStore) UpdateReservation(r services.Reservation) (services.Reservation, error) {
+	return withTx(s, func(tx *jsonTx) (services.Reservation, error) {
+		return tx.UpdateReservation(r)
+	})
+}
+
+// DeleteReservation supprime une réservation si elle existe.
+func (s *JSONStore) DeleteReservation(resID services.ID) error {
+	_, err := withTx(s, func(tx *jsonTx) (struct{}, error) {
+		return struct{}{}, tx.DeleteReservation(resID)
+	})
+	return err
+}
+
+// CreateReservation enregistre une réservation.
+// Le créneau référencé doit exister.
+func (t *jsonTx) CreateReservation(r services.Reservation) (services.Reservation, error) {
+	if _, err := t.GetSlot(r.SlotID); err != nil {
+		return services.Reservation{}, err
+	}
+	if r.ID == "" {
+		r.ID = newID("res")
+	}
+
+	t.touch()
+	t.db.Reservations = append(t.db.Reservations, r)
+
+	return r, nil
+}
+
+// ListReservationsByEmail recherche toutes les réservations d’un utilisateur.
+func (t *jsonTx) ListReservationsByEmail(email string) ([]services.Reservation, error) {
+	var out []services.Reservation
+	for _, r := range t.db.Reservations {
+		if r.UserEmail == email {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// ListReservationsBySlot retourne les réservations d’un créneau donné.
+func (t *jsonTx) ListReservationsBySlot(slotID services.ID) ([]services.Reservation, error) {
+	var out []services.Reservation
+	for _, r := range t.db.Reservations {
+		if r.SlotID == slotID {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// CountSeats compte les places occupées de plusieurs créneaux
+// (un seul parcours des réservations et des places retenues).
+func (t *jsonTx) CountSeats(slotIDs []services.ID, now time.Time) (map[services.ID]services.SeatUsage, error) {
+	wanted := make(map[services.ID]bool, len(slotIDs))
+	for _, id := range slotIDs {
+		wanted[id] = true
+	}
+
+	out := map[services.ID]services.SeatUsage{}
+	for _, r := range t.db.Reservations {
+		if wanted[r.SlotID] && !r.Cancelled() {
+			u := out[r.SlotID]
+			u.Booked += r.SeatCount()
+			out[r.SlotID] = u
+		}
+	}
+	for _, h := range t.db.Holds {
+		if wanted[h.SlotID] && h.ExpiresAt.After(now) {
+			u := out[h.SlotID]
+			u.Held += h.SeatCount()
+			out[h.SlotID] = u
+		}
+	}
+	return out, nil
+}
+
+// GetReservation récupère une réservation par ID.
+func (t *jsonTx) GetReservation(resID services.ID) (services.Reservation, error) {
+	for _, r := range t.db.Reservations {
+		if r.ID == resID {
+			return r, nil
+		}
+	}
+	return services.Reservation{}, services.ErrReservationNotFound
+}
+
+// UpdateReservation remplace une réservation existante.
+// En cas de déplacement, le nouveau créneau doit exister ; une réservation
+// d'un créneau archivé reste modifiable (annulation).
+func (t *jsonTx) UpdateReservation(r services.Reservation) (services.Reservation, error) {
+	for i := range t.db.Reservations {
+		if t.db.Reservations[i].ID == r.ID {
+			if t.db.Reservations[i].SlotID != r.SlotID {
+				if _, err := t.GetSlot(r.SlotID); err != nil {
+					return services.Reservation{}, err
+				}
+			}
+			t.touch()
+			t.db.Reservations[i] = r
+			return r, nil
+		}
+	}
+	return services.Reservation{}, services.ErrReservationNotFound
+}
+
+// DeleteReservation supprime une réservation si elle existe.
+func (t *jsonTx) DeleteReservation(resID services.ID) error {
+	idx := -1
+
+	for i, r := range t.db.Reservations {
+		if r.ID == resID {
+			idx = i
+			break
+		}
+	}
+
+	if idx < 0 {
+		return services.ErrReservationNotFound
+	}
+
+	// Suppression propre du slice
+	t.touch()
+	t.db.Reservations = append(
+		t.db.Reservations[:idx],
+		t.db.Reservations[idx+1:]...,
+	)
+	t.dropReminders(map[services.ID]bool{resID: true})
+
+	return nil
+}
+
+//
+// ---------- Utilisateurs et sessions ----------
+//
+
+// CreateUser enregistre un nouveau compte.
+func (s *JSONStore) CreateUser(u services.User) (services.User, error) {
+	return withTx(s, func(tx *jsonTx) (services.User, error) {
+		return tx.CreateUser(u)
+	})
+}
+
+// GetUser retourne un compte selon son email.
+func (s *JSONStore) GetUser(email string) (services.User, error) {
+	return withTx(s, func(tx *jsonTx) (services.User, error) {
+		return tx.GetUser(email)
+	})
+}
+
+// ListUsers renvoie tous les comptes.
+func (s *JSONStore) ListUsers() ([]services.User, error) {
+	return withTx(s, func(tx *jsonTx) ([]services.User, error) {
+		return tx.ListUsers()
+	})
+}
+
+// UpdateUser remplace un compte existant.
+func (s *JSONStore) UpdateUser(u services.User) (services.User, error) {
+	return withTx(s, func(tx *jsonTx) (services.User, error) {
+		return tx.UpdateUser(u)
+	})
+}
+
+// CreateSession enregistre une session.
+func (s *JSONStore) CreateSession(sess services.Session) (services.Session, error) {
+	return withTx(s, func(tx *jsonTx) (services.Session, error) {
+		return tx.CreateSession(sess)
+	})
+}
+
+// GetSession retourne une session selon son ID.
+func (s *JSONStore) GetSession(id services.ID) (services.Session, error) {
+	return withTx(s, func(tx *jsonTx) (services.Session, error) {
+		return tx.GetSession(id)
+	})
+}
+
+// DeleteSession supprime une session.
+func (s *JSONStore) DeleteSession(id services.ID) error {
+	_, err := withTx(s, func(tx *jsonTx) (struct{}, error) {
+		return struct{}{}, tx.DeleteSession(id)
+	})
+	return err
+}
+
+// DeleteExpiredSessions supprime les sessions expirées à la date now.
+func (s *JSONStore) DeleteExpiredSessions(now time.Time) (int, error) {
+	return withTx(s, func(tx *jsonTx) (int, error) {
+		return tx.DeleteExpiredSessions(now)
+	})
+}
+
+// CreateUser enregistre un nouveau compte (email unique).
+func (t *jsonTx) CreateUser(u services.User) (services.User, error) {
+	if _, err := t.GetUser(u.Email); err == nil {
+		return services.User{}, services.ErrUserExists
+	}
+
+	t.touch()
+	t.db.Users = append(t.db.Users, u)
+
+	return u, nil
+}
+
+// GetUser retourne un compte selon son email.
+func (t *jsonTx) GetUser(email string) (services.User, error) {
+	for _, u := range t.db.Users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return services.User{}, services.ErrUserNotFound
+}
+
+// ListUsers renvoie tous les comptes.
+func (t *jsonTx) ListUsers() ([]services.User, error) {
+	return append([]services.User(nil), t.db.Users...), nil
+}
+
+// UpdateUser remplace un compte existant.
+func (t *jsonTx) UpdateUser(u services.User) (services.User, error) {
+	for i := range t.db.Users {
+		if t.db.Users[i].Email == u.Email {
+			t.touch()
+			t.db.Users[i] = u
+			return u, nil
+		}
+	}
+	return services.User{}, services.ErrUserNotFound
+}
+
+// CreateSession enregistre une session.
+func (t *jsonTx) CreateSession(sess services.Session) (services.Session, error) {
+	if _, err := t.GetUser(sess.UserEmail); err != nil {
+		return services.Session{}, err
+	}
+
+	t.touch()
+	t.db.Sessions = append(t.db.Sessions, sess)
+
+	return sess, nil
+}
+
+// GetSession retourne une session selon son ID.
+func (t *jsonTx) GetSession(id services.ID) (services.Session, error) {
+	for _, sess := range t.db.Sessions {
+		if sess.ID == id {
+			return sess, nil
+		}
+	}
+	return services.Session{}, services.ErrSessionNotFound
+}
+
+// DeleteSession supprime une session.
+func (t *jsonTx) DeleteSession(id services.ID) error {
+	for i, sess := range t.db.Sessions {
+		if sess.ID == id {
+			t.touch()
+			t.db.Sessions = append(t.db.Sessions[:i], t.db.Sessions[i+1:]...)
+			return nil
+		}
+	}
+	return services.ErrSessionNotFound
+}
+
+// DeleteExpiredSessions supprime les sessions expirées à la date now.
+func (t *jsonTx) DeleteExpiredSessions(now time.Time) (int, error) {
+	var kept []services.Session
+	for _, sess := range t.db.Sessions {
+		if sess.ExpiresAt.After(now) {
+			kept = append(kept, sess)
+		}
+	}
+
+	n := len(t.db.Sessions) - len(kept)
+	if n > 0 {
+		t.touch()
+		t.db.Sessions = kept
+	}
+
+	return n, nil
+}
+
+//
+// ---------- Liens de connexion ----------
+//
+
+// CreateLoginLink enregistre un lien de connexion.
+func (s *JSONStore) CreateLoginLink(l services.LoginLink) (services.LoginLink, error) {
+	return withTx(s, func(tx *jsonTx) (services.LoginLink, error) {
+		return tx.CreateLoginLink(l)
+	})
+}
+
+// GetLoginLink retourne un lien selon son ID.
+func (s *JSONStore) GetLoginLink(id services.ID) (services.LoginLink, error) {
+	return withTx(s, func(tx *jsonTx) (services.LoginLink, error) {
+		return tx.GetLoginLink(id)
+	})
+}
+
+// DeleteLoginLink supprime un lien.
+func (s *JSONStore) DeleteLoginLink(id services.ID) error {
+	_, err := withTx(s, func(tx *jsonTx) (struct{}, error) {
+		return struct{}{}, tx.DeleteLoginLink(id)
+	})
+	return err
+}
+
+// DeleteExpiredLoginLinks supprime les liens expirés à la date now.
+func (s *JSONStore) DeleteExpiredLoginLinks(now time.Time) (int, error) {
+	return withTx(s, func(tx *jsonTx) (int, error) {
+		return tx.DeleteExpiredLoginLinks(now)
+	})
+}
+
+// CreateLoginLink enregistre un lien de connexion.
+func (t *jsonTx) CreateLoginLink(l services.LoginLink) (services.LoginLink, error) {
+	t.touch()
+	t.db.LoginLinks = append(t.db.LoginLinks, l)
+
+	return l, nil
+}
+
+// GetLoginLink retourne un lien selon son ID.
+func (t *jsonTx) GetLoginLink(id services.ID) (services.LoginLink, error) {
+	for _, l := range t.db.LoginLinks {
+		if l.ID == id {
+			return l, nil
+		}
+	}
+	return services.LoginLink{}, services.ErrLoginLinkNotFound
+}
+
+// DeleteLoginLink supprime un lien.
+func (t *jsonTx) DeleteLoginLink(id services.ID) error {
+	for i, l := range t.db.LoginLinks {
+		if l.ID == id {
+			t.touch()
+			t.db.LoginLinks = append(t.db.LoginLinks[:i], t.db.LoginLinks[i+1:]...)
+			return nil
+		}
+	}
+	return services.ErrLoginLinkNotFound
+}
+
+// DeleteExpiredLoginLinks supprime les liens expirés à la date now.
+func (t *jsonTx) DeleteExpiredLoginLinks(now time.Time) (int, error) {
+	var kept []services.LoginLink
+	for _, l := range t.db.LoginLinks {
+		if l.ExpiresAt.After(now) {
+			kept = append(kept, l)
+		}
+	}
+
+	n := len(t.db.LoginLinks) - len(kept)
+	if n > 0 {
+		t.touch()
+		t.db.LoginLinks = kept
+	}
+
+	return n, nil
+}
+
+//
+// ---------- Rappels ----------
+//
+
+// ListSlotsBetween retourne les créneaux tels que from < datetime <= to, par date.
+func (s *JSONStore) ListSlotsBetween(from, to time.Time) ([]services.Slot, error) {
+	return withTx(s, func(tx *jsonTx) ([]services.Slot, error) {
+		return tx.ListSlotsBetween(from, to)
+	})
+}
+
+// HasReminder indique si le rappel a déjà été envoyé pour cette date de créneau.
+func (s *JSONStore) HasReminder(resID services.ID, slotTime time.Time, offsetMinutes int) (bool, error) {
+	return withTx(s, func(tx *jsonTx) (bool, error) {
+		return tx.HasReminder(resID, slotTime, offsetMinutes)
+	})
+}
+
+// CreateReminder enregistre un rappel envoyé.
+func (s *JSONStore) CreateReminder(r services.Reminder) error {
+	_, err := withTx(s, func(tx *jsonTx) (struct{}, error) {
+		return struct{}{}, tx.CreateReminder(r)
+	})
+	return err
+}
+
+// ListSlotsBetween retourne les créneaux tels que from < datetime <= to, par date.
+func (t *jsonTx) ListSlotsBetween(from, to time.Time) ([]services.Slot, error) {
+	var out []services.Slot
+	for _, sl := range t.db.Slots {
+		if sl.DeletedAt == nil && sl.Datetime.After(from) && !sl.Datetime.After(to) {
+			out = append(out, sl)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Datetime.Before(out[j].Datetime)
+	})
+	return out, nil
+}
+
+// HasReminder indique si le rappel a déjà été envoyé pour cette date de
+// créneau (un rappel enregistré sans date compte pour toutes).
+func (t *jsonTx) HasReminder(resID services.ID, slotTime time.Time, offsetMinutes int) (bool, error) {
+	for _, r := range t.db.Reminders {
+		if r.ReservationID == resID && r.OffsetMinutes == offsetMinutes &&
+			(r.SlotDatetime.IsZero() || r.SlotDatetime.Equal(slotTime)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// dropReminders supprime les rappels des réservations resIDs
+// (équivalent du ON DELETE CASCADE de la base SQL).
+func (t *jsonTx) dropReminders(resIDs map[services.ID]bool) {
+	var out []services.Reminder
+	for _, r := range t.db.Reminders {
+		if !resIDs[r.ReservationID] {
+			out = append(out, r)
+		}
+	}
+	t.db.Reminders = out
+}
+
+// CreateReminder enregistre un rappel envoyé.
+func (t *jsonTx) CreateReminder(r services.Reminder) error {
+	t.touch()
+	t.db.Reminders = append(t.db.Reminders, r)
+	return nil
+}
+
+//
+// ---------- Liste d'attente ----------
+//
+
+// AddWaitlistEntry inscrit un utilisateur sur la liste d'attente d'un créneau.
+func (s *JSONStore) AddWaitlistEntry(w services.WaitlistEntry) (services.WaitlistEntry, error) {
+	return withTx(s, func(tx *jsonTx) (services.WaitlistEntry, error) {
+		return tx.AddWaitlistEntry(w)
+	})
+}
+
+// GetWaitlistEntry retourne une inscription selon son ID.
+func (s *JSONStore) GetWaitlistEntry(id services.ID) (services.WaitlistEntry, error) {
+	return withTx(s, func(tx *jsonTx) (services.WaitlistEntry, error) {
+		return tx.GetWaitlistEntry(id)
+	})
+}
+
+// ListWaitlistBySlot retourne la file d'un créneau, par ordre d'arrivée.
+func (s *JSONStore) ListWaitlistBySlot(slotID services.ID) ([]services.WaitlistEntry, error) {
+	return withTx(s, func(tx *jsonTx) ([]services.WaitlistEntry, error) {
+		return tx.ListWaitlistBySlot(slotID)
+	})
+}
+
+// ListWaitlistByEmail retourne les inscriptions d'un utilisateur.
+func (s *JSONStore) ListWaitlistByEmail(email string) ([]services.WaitlistEntry, error) {
+	return withTx(s, func(tx *jsonTx) ([]services.WaitlistEntry, error) {
+		return tx.ListWaitlistByEmail(email)
+	})
+}
+
+// DeleteWaitlistEntry retire une inscription.
+func (s *JSONStore) DeleteWaitlistEntry(id services.ID) error {
+	_, err := withTx(s, func(tx *jsonTx) (struct{}, error) {
+		return struct{}{}, tx.DeleteWaitlistEntry(id)
+	})
+	return err
+}
+
+// AddWaitlistEntry inscrit un utilisateur (une seule fois par créneau).
+func (t *jsonTx) AddWaitlistEntry(w services.WaitlistEntry) (services.WaitlistEntry, error) {
+	if _, err := t.GetSlot(w.SlotID); err != nil {
+		return services.WaitlistEntry{}, err
+	}
+	for _, e := range t.db.Waitlist {
+		if e.SlotID == w.SlotID && e.UserEmail == w.UserEmail {
+			return services.WaitlistEntry{}, services.ErrAlreadyWaiting
+		}
+	}
+	if w.ID == "" {
+		w.ID = newID("wl")
+	}
+
+	t.touch()
+	t.db.Waitlist = append(t.db.Waitlist, w)
+
+	return w, nil
+}
+
+// GetWaitlistEntry retourne une inscription selon son ID.
+func (t *jsonTx) GetWaitlistEntry(id services.ID) (services.WaitlistEntry, error) {
+	for _, w := range t.db.Waitlist {
+		if w.ID == id {
+			return w, nil
+		}
+	}
+	return services.WaitlistEntry{}, services.ErrWaitlistNotFound
+}
+
+// ListWaitlistBySlot retourne la file d'un créneau, par ordre d'arrivée.
+func (t *jsonTx) ListWaitlistBySlot(slotID services.ID) ([]services.WaitlistEntry, error) {
+	var out []services.WaitlistEntry
+	for _, w := range t.db.Waitlist {
+		if w.SlotID == slotID {
+			out = append(out, w)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	return out, nil
+}
+
+// ListWaitlistByEmail retourne les inscriptions d'un utilisateur.
+func (t *jsonTx) ListWaitlistByEmail(email string) ([]services.WaitlistEntry, error) {
+	var out []services.WaitlistEntry
+	for _, w := range t.db.Waitlist {
+		if w.UserEmail == email {
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
+
+// DeleteWaitlistEntry retire une inscription.
+func (t *jsonTx) DeleteWaitlistEntry(id services.ID) error {
+	for i, w := range t.db.Waitlist {
+		if w.ID == id {
+			t.touch()
+			t.db.Waitlist = append(t.db.Waitlist[:i], t.db.Waitlist[i+1:]...)
+			return nil
+		}
+	}
+	return services.ErrWaitlistNotFound
+}
+
+//
+// ---------- Places retenues ----------
+//
+
+// CreateHold enregistre une place retenue.
+func (s *JSONStore) CreateHold(h services.Hold) (services.Hold, error) {
+	return withTx(s, func(tx *jsonTx) (services.Hold, error) {
+		return tx.CreateHold(h)
+	})
+}
+
+// GetHold retourne une place retenue selon son ID.
+func (s *JSONStore) GetHold(id services.ID) (services.Hold, error) {
+	return withTx(s, func(tx *jsonTx) (services.Hold, error) {
+		return tx.GetHold(id)
+	})
+}
+
+// ListHoldsBySlot retourne les places retenues d'un créneau, expirées comprises.
+func (s *JSONStore) ListHoldsBySlot(slotID services.ID) ([]services.Hold, error) {
+	return withTx(s, func(tx *jsonTx) ([]services.Hold, error) {
+		return tx.ListHoldsBySlot(slotID)
+	})
+}
+
+// ListHoldsByEmail retourne les places retenues d'un utilisateur, expirées comprises.
+func (s *JSONStore) ListHoldsByEmail(email string) ([]services.Hold, error) {
+	return withTx(s, func(tx *jsonTx) ([]services.Hold, error) {
+		return tx.ListHoldsByEmail(email)
+	})
+}
+
+// ListExpiredHolds retourne les places retenues expirées à la date now.
+func (s *JSONStore) ListExpiredHolds(now time.Time) ([]services.Hold, error) {
+	return withTx(s, func(tx *jsonTx) ([]services.Hold, error) {
+		return tx.ListExpiredHolds(now)
+	})
+}
+
+// DeleteHold supprime une place retenue.
+func (s *JSONStore) DeleteHold(id services.ID) error {
+	_, err := withTx(s, func(tx *jsonTx) (struct{}, error) {
+		return struct{}{}, tx.DeleteHold(id)
+	})
+	return err
+}
+
+// CreateHold enregistre une place retenue. Le créneau doit exister.
+func (t *jsonTx) CreateHold(h services.Hold) (services.Hold, error) {
+	if _, err := t.GetSlot(h.SlotID); err != nil {
+		return services.Hold{}, err
+	}
+	if h.ID == "" {
+		h.ID = newID("hld")
+	}
+
+	t.touch()
+	t.db.Holds = append(t.db.Holds, h)
+
+	return h, nil
+}
+
+// GetHold retourne une place retenue selon son ID.
+func (t *jsonTx) GetHold(id services.ID) (services.Hold, error) {
+	for _, h := range t.db.Holds {
+		if h.ID == id {
+			return h, nil
+		}
+	}
+	return services.Hold{}, services.ErrHoldNotFound
+}
+
+// ListHoldsBySlot retourne les places retenues d'un créneau, expirées comprises.
+func (t *jsonTx) ListHoldsBySlot(slotID services.ID) ([]services.Hold, error) {
+	var out []services.Hold
+	for _, h := range t.db.Holds {
+		if h.SlotID == slotID {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+// ListHoldsByEmail retourne les places retenues d'un utilisateur, expirées comprises.
+func (t *jsonTx) ListHoldsByEmail(email string) ([]services.Hold, error) {
+	var out []services.Hold
+	for _, h := range t.db.Holds {
+		if h.UserEmail == email {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+// ListExpiredHolds retourne les places retenues expirées à la date now.
+func (t *jsonTx) ListExpiredHolds(now time.Time) ([]services.Hold, error) {
+	var out []services.Hold
+	for _, h := range t.db.Holds {
+		if !h.ExpiresAt.After(now) {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+// DeleteHold supprime une place retenue.
+func (t *jsonTx) DeleteHold(id services.ID) error {
+	for i, h := range t.db.Holds {
+		if h.ID == id {
+			t.touch()
+			t.db.Holds = append(t.db.Holds[:i], t.db.Holds[i+1:]...)
+			return nil
+		}
+	}
+	return services.ErrHoldNotFound
+}
+
+//
+// ---------- Intégrité ----------
+//
+
+// CheckIntegrity recherche les slots et réservations orphelins
+// (le JSON n'a pas de clés étrangères) et les supprime si repair est vrai.
+func (s *JSONStore) CheckIntegrity(repair bool) (services.IntegrityReport, error) {
+	return withTx(s, func(tx *jsonTx) (services.IntegrityReport, error) {
+		return tx.CheckIntegrity(repair)
+	})
+}
+
+// CheckIntegrity recherche les slots et réservations orphelins.
+func (t *jsonTx) CheckIntegrity(repair bool) (services.IntegrityReport, error) {
+	report := services.IntegrityReport{
+		OrphanSlots:        []services.Slot{},
+		OrphanReservations: []services.Reservation{},
+	}
+
+	svcs := map[services.ID]bool{}
+	for _, svc := range t.db.Services {
+		svcs[svc.ID] = true
+	}
+
+	// Un slot n'est valide que si son service existe
+	validSlots := map[services.ID]bool{}
+	var slots []services.Slot
+	for _, sl := range t.db.Slots {
+		if !svcs[sl.ServiceID] {
+			report.OrphanSlots = append(report.OrphanSlots, sl)
+			continue
+		}
+		validSlots[sl.ID] = true
+		slots = append(slots, sl)
+	}
+
+	var res []services.Reservation
+	orphans := map[services.ID]bool{}
+	for _, r := range t.db.Reservations {
+		if !validSlots[r.SlotID] {
+			report.OrphanReservations = append(report.OrphanReservations, r)
+			orphans[r.ID] = true
+			continue
+		}
+		res = append(res, r)
+	}
+
+	if repair && (len(report.OrphanSlots) > 0 || len(report.OrphanReservations) > 0) {
+		t.touch()
+		t.db.Slots = slots
+		t.db.Reservations = res
+		t.dropReminders(orphans)
+
+		var wl []services.WaitlistEntry
+		for _, w := range t.db.Waitlist {
+			if validSlots[w.SlotID] {
+				wl = append(wl, w)
+			}
+		}
+		t.db.Waitlist = wl
+
+		var holds []services.Hold
+		for _, h := range t.db.Holds {
+			if validSlots[h.SlotID] {
+				holds = append(holds, h)
+			}
+		}
+		t.db.Holds = holds
+		report.Repaired = true
+	}
+
+	return report, nil
+}
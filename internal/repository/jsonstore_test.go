@@ -0,0 +1,66 @@
+package repository_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gestionsvc/internal/repository"
+	"gestionsvc/internal/services"
+)
+
+// Une sauvegarde qui échoue après l'écriture de manifest.pending laisse le
+// store inutilisable tant que la reprise échoue ; ensuite la mémoire et le
+// disque reflètent tous deux la sauvegarde validée, y compris après
+// réouverture.
+func TestJSONStoreInterruptedSave(t *testing.T) {
+	dir := t.TempDir()
+	store, err := repository.NewJSONStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CreateService(services.Service{Name: "Yoga", Duration: 60}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Un dossier non vide à la place de resources.json (renommé en dernier)
+	// fait échouer save après la validation.
+	obstacle := filepath.Join(dir, "resources.json")
+	if err := os.Remove(obstacle); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(obstacle, "x"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.CreateService(services.Service{Name: "Pilates", Duration: 45}); err == nil {
+		t.Fatal("CreateService succeeded, want a save error")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "manifest.pending")); err != nil {
+		t.Fatalf("manifest.pending: %v", err)
+	}
+	if _, err := store.ListServices(); err == nil {
+		t.Fatal("ListServices succeeded while the save cannot be recovered")
+	}
+
+	if err := os.RemoveAll(obstacle); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := repository.NewJSONStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, repo := range map[string]services.Repository{"same store": store, "reopened": reopened} {
+		list, err := repo.ListServices()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(list) != 2 {
+			t.Fatalf("%s: %d services, want 2 (the interrupted save was validated)", name, len(list))
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "manifest.pending")); !os.IsNotExist(err) {
+		t.Fatalf("manifest.pending still present after recovery (err = %v)", err)
+	}
+}
@@ -0,0 +1,174 @@
+package repository_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gestionsvc/internal/services"
+)
+
+// limitsFixture prépare un service de réservation avec les limites l,
+// une horloge réglable et deux services vides.
+type limitsFixture struct {
+	b          *services.BookingService
+	now        time.Time
+	yoga, pila services.Service
+}
+
+func newLimitsFixture(t *testing.T, repo services.Repository, l services.BookingLimits) *limitsFixture {
+	t.Helper()
+	f := &limitsFixture{now: time.Date(2099, 1, 5, 6, 0, 0, 0, time.UTC)}
+	f.b = services.NewBookingService(repo,
+		services.WithClock(func() time.Time { return f.now }),
+		services.WithBookingLimits(l),
+		services.WithHoldTTL(time.Hour),
+	)
+
+	var err error
+	if f.yoga, err = f.b.CreateService(services.Service{Name: "Yoga", Duration: 60}); err != nil {
+		t.Fatal(err)
+	}
+	if f.pila, err = f.b.CreateService(services.Service{Name: "Pilates", Duration: 60}); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// slot crée un créneau d'une place à la date at.
+func (f *limitsFixture) slot(t *testing.T, svc services.Service, at time.Time) services.Slot {
+	t.Helper()
+	s, err := f.b.AddSlot(svc.ID, at.Format(time.RFC3339), 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+const limitsUser = "alice@example.com"
+
+func TestMaxActiveLimit(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repo services.Repository) {
+		f := newLimitsFixture(t, repo, services.BookingLimits{MaxActive: 2})
+		day := f.now.AddDate(0, 0, 1)
+		a := f.slot(t, f.yoga, day)
+		b := f.slot(t, f.pila, day.AddDate(0, 0, 1))
+		c := f.slot(t, f.yoga, day.AddDate(0, 0, 2))
+
+		res, err := f.b.Book(a.ID, limitsUser, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.b.Book(b.ID, limitsUser, 1); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.b.Book(c.ID, limitsUser, 1); !errors.Is(err, services.ErrTooManyReservations) {
+			t.Fatalf("third booking: err = %v, want ErrTooManyReservations", err)
+		}
+		// la réservation déplacée n'est pas comptée
+		if _, err := f.b.Reschedule(res.ID, c.ID, limitsUser); err != nil {
+			t.Fatalf("Reschedule: %v", err)
+		}
+	})
+}
+
+func TestMaxPerServiceLimit(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repo services.Repository) {
+		f := newLimitsFixture(t, repo, services.BookingLimits{MaxPerService: 1})
+		day := f.now.AddDate(0, 0, 1)
+		a := f.slot(t, f.yoga, day)
+		b := f.slot(t, f.yoga, day.AddDate(0, 0, 1))
+		c := f.slot(t, f.pila, day.AddDate(0, 0, 1))
+
+		if _, err := f.b.Book(a.ID, limitsUser, 1); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.b.Book(b.ID, limitsUser, 1); !errors.Is(err, services.ErrServiceLimit) {
+			t.Fatalf("same service: err = %v, want ErrServiceLimit", err)
+		}
+		if _, err := f.b.Book(c.ID, limitsUser, 1); err != nil {
+			t.Fatalf("other service: %v", err)
+		}
+	})
+}
+
+func TestMaxPerDayLimit(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repo services.Repository) {
+		f := newLimitsFixture(t, repo, services.BookingLimits{MaxPerDay: 1})
+		day := f.now.AddDate(0, 0, 1)
+		a := f.slot(t, f.yoga, day)
+		b := f.slot(t, f.pila, day.Add(3*time.Hour))
+		c := f.slot(t, f.pila, day.AddDate(0, 0, 1))
+
+		if _, err := f.b.Book(a.ID, limitsUser, 1); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.b.Book(b.ID, limitsUser, 1); !errors.Is(err, services.ErrDailyLimit) {
+			t.Fatalf("same day: err = %v, want ErrDailyLimit", err)
+		}
+		if _, err := f.b.Book(c.ID, limitsUser, 1); err != nil {
+			t.Fatalf("next day: %v", err)
+		}
+	})
+}
+
+func TestMinLeadTimeLimit(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repo services.Repository) {
+		f := newLimitsFixture(t, repo, services.BookingLimits{MinLeadTime: 2 * time.Hour})
+		soon := f.slot(t, f.yoga, f.now.Add(time.Hour))
+		later := f.slot(t, f.yoga, f.now.Add(150*time.Minute))
+
+		if _, err := f.b.Book(soon.ID, limitsUser, 1); !errors.Is(err, services.ErrTooLate) {
+			t.Fatalf("Book: err = %v, want ErrTooLate", err)
+		}
+		if _, err := f.b.HoldSeats(soon.ID, limitsUser, 1); !errors.Is(err, services.ErrTooLate) {
+			t.Fatalf("HoldSeats: err = %v, want ErrTooLate", err)
+		}
+
+		// une place retenue à temps reste confirmable une fois le délai passé
+		hold, err := f.b.HoldSeats(later.ID, limitsUser, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.now = f.now.Add(45 * time.Minute)
+		if _, err := f.b.ConfirmHold(hold.ID, limitsUser); err != nil {
+			t.Fatalf("ConfirmHold past lead time: %v", err)
+		}
+	})
+}
+
+// Les places retenues non expirées comptent dans les limites, sauf celle
+// du créneau demandé.
+func TestHoldsCountTowardLimits(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repo services.Repository) {
+		f := newLimitsFixture(t, repo, services.BookingLimits{MaxActive: 1, MaxPerService: 1, MaxPerDay: 1})
+		day := f.now.AddDate(0, 0, 1)
+		a := f.slot(t, f.yoga, day)
+		b := f.slot(t, f.pila, day.AddDate(0, 0, 1))
+
+		hold, err := f.b.HoldSeats(a.ID, limitsUser, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.b.Book(b.ID, limitsUser, 1); !errors.Is(err, services.ErrTooManyReservations) {
+			t.Fatalf("Book with a hold: err = %v, want ErrTooManyReservations", err)
+		}
+		if _, err := f.b.HoldSeats(b.ID, limitsUser, 1); !errors.Is(err, services.ErrTooManyReservations) {
+			t.Fatalf("second hold: err = %v, want ErrTooManyReservations", err)
+		}
+		if _, err := f.b.ConfirmHold(hold.ID, limitsUser); err != nil {
+			t.Fatalf("ConfirmHold: %v", err)
+		}
+
+		// une place retenue expirée ne compte plus
+		f.now = f.now.Add(2 * time.Hour)
+		if _, err := f.b.HoldSeats(b.ID, "bob@example.com", 1); err != nil {
+			t.Fatal(err)
+		}
+		f.now = f.now.Add(2 * time.Hour)
+		c := f.slot(t, f.pila, day.AddDate(0, 0, 2))
+		if _, err := f.b.Book(c.ID, "bob@example.com", 1); err != nil {
+			t.Fatalf("Book after hold expiry: %v", err)
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"gestionsvc/internal/services"
+)
+
+// openAtVersion crée à path une base au schéma de la version v : schéma
+// initial puis les v premières migrations, comme une base écrite par une
+// version précédente du serveur.
+func openAtVersion(t *testing.T, path string, v int) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(sqlSchema); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < v; i++ {
+		if _, err := db.Exec(sqlMigrations[i]); err != nil {
+			t.Fatalf("migration %d: %v", i+1, err)
+		}
+	}
+	if _, err := db.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, v)); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// Une base existante, quelle que soit sa version, est migrée jusqu'à la
+// dernière sans perdre ses données ; les colonnes ajoutées prennent leur
+// valeur par défaut.
+func TestSQLMigrations(t *testing.T) {
+	tests := []struct {
+		name      string
+		version   int
+		extra     string // données propres à cette version
+		seats     int
+		status    services.ReservationStatus
+		reminders int
+	}{
+		{name: "initial schema", version: 0, seats: 1, status: services.StatusConfirmed},
+		{
+			name:      "before reminders rekey",
+			version:   3,
+			extra:     `INSERT INTO reminders (reservation_id, offset_minutes, sent_at) VALUES ('res_1', 60, '2099-01-05T08:00:00.000000000Z')`,
+			seats:     1,
+			status:    services.StatusConfirmed,
+			reminders: 1,
+		},
+		{
+			name:    "with seats and status",
+			version: 7,
+			extra:   `UPDATE reservations SET seats = 2, status = 'cancelled' WHERE id = 'res_1'`,
+			seats:   2,
+			status:  services.StatusCancelled,
+		},
+		{name: "up to date", version: len(sqlMigrations), seats: 1, status: services.StatusConfirmed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.db")
+			db := openAtVersion(t, path, tt.version)
+			seed := []string{
+				`INSERT INTO services (id, name, description, duration) VALUES ('svc_1', 'Yoga', '', 60)`,
+				`INSERT INTO slots (id, service_id, datetime, capacity) VALUES ('slt_1', 'svc_1', '2099-01-05T09:00:00.000000000Z', 3)`,
+				`INSERT INTO reservations (id, slot_id, user_email, created_at) VALUES ('res_1', 'slt_1', 'alice@example.com', '2099-01-01T09:00:00.000000000Z')`,
+				`INSERT INTO users (email, password_hash, created_at) VALUES ('alice@example.com', '', '2099-01-01T09:00:00.000000000Z')`,
+			}
+			if tt.extra != "" {
+				seed = append(seed, tt.extra)
+			}
+			for _, q := range seed {
+				if _, err := db.Exec(q); err != nil {
+					t.Fatalf("%s: %v", q, err)
+				}
+			}
+			db.Close()
+
+			// deux ouvertures : la seconde ne doit rien réappliquer
+			for i := 0; i < 2; i++ {
+				store, err := NewSQLStore(path)
+				if err != nil {
+					t.Fatalf("open #%d: %v", i+1, err)
+				}
+				var version int
+				if err := store.db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+					t.Fatal(err)
+				}
+				if version != len(sqlMigrations) {
+					t.Fatalf("user_version = %d, want %d", version, len(sqlMigrations))
+				}
+				store.Close()
+			}
+
+			store, err := NewSQLStore(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer store.Close()
+
+			svc, err := store.GetService("svc_1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if svc.Name != "Yoga" || svc.Owner != "" || svc.MaxPartySize != 0 || svc.DeletedAt != nil {
+				t.Fatalf("service = %+v", svc)
+			}
+			if _, err := store.GetSlot("slt_1"); err != nil {
+				t.Fatal(err)
+			}
+			u, err := store.GetUser("alice@example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if u.Role.OrDefault() != services.RoleCustomer {
+				t.Fatalf("role = %q, want customer", u.Role)
+			}
+
+			list, err := store.ListReservationsByEmail("alice@example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(list) != 1 || list[0].Seats != tt.seats || list[0].Status != tt.status {
+				t.Fatalf("reservations = %+v, want 1 with %d seat(s), %s", list, tt.seats, tt.status)
+			}
+
+			var reminders int
+			if err := store.db.QueryRow(`SELECT COUNT(*) FROM reminders`).Scan(&reminders); err != nil {
+				t.Fatal(err)
+			}
+			if reminders != tt.reminders {
+				t.Fatalf("reminders = %d, want %d", reminders, tt.reminders)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package repository_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"gestionsvc/internal/repository"
+	"gestionsvc/internal/services"
+)
+
+// parityIDs reconnaît les identifiants générés ("svc_1731965329823345000",
+// "ser_90b12455cbd2c008") : ils diffèrent d'un store à l'autre et sont
+// remplacés par leur rang.
+var parityIDs = regexp.MustCompile(`"([a-z]+)_[0-9a-f]+"`)
+
+// snapshot sérialise v en JSON, identifiants remplacés par "svc#1", "slt#2"...
+// dans l'ordre de première apparition.
+func snapshot(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]string{}
+	return parityIDs.ReplaceAllStringFunc(string(b), func(id string) string {
+		if _, ok := seen[id]; !ok {
+			prefix := parityIDs.FindStringSubmatch(id)[1]
+			seen[id] = fmt.Sprintf(`"%s#%d"`, prefix, len(seen)+1)
+		}
+		return seen[id]
+	})
+}
+
+// errString rend une erreur comparable dans un snapshot.
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// Les deux stores donnent les mêmes résultats pour les mêmes opérations.
+func TestStoreParity(t *testing.T) {
+	start := time.Date(2099, 1, 5, 9, 0, 0, 0, time.UTC)
+	at := func(days, hours int) string {
+		return start.AddDate(0, 0, days).Add(time.Duration(hours) * time.Hour).Format(time.RFC3339)
+	}
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T, b *services.BookingService) any
+	}{
+		{"services", func(t *testing.T, b *services.BookingService) any {
+			yoga, err := b.CreateService(services.Service{Name: "Yoga", Description: "Doux", Duration: 60, MaxPartySize: 4})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := b.CreateService(services.Service{Name: "Pilates", Duration: 45}); err != nil {
+				t.Fatal(err)
+			}
+			name := "Yoga doux"
+			_, err = b.UpdateService(yoga.ID, services.ServiceUpdate{Name: &name})
+			list, lerr := b.ListServices()
+			return []any{errString(err), list, errString(lerr)}
+		}},
+		{"slots and capacity", func(t *testing.T, b *services.BookingService) any {
+			svc, err := b.CreateService(services.Service{Name: "Yoga", Duration: 60})
+			if err != nil {
+				t.Fatal(err)
+			}
+			slot, err := b.AddSlot(svc.ID, at(1, 0), 2, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, overlap := b.AddSlot(svc.ID, at(1, 0), 2, nil)
+			_, err1 := b.Book(slot.ID, "alice@example.com", 1)
+			_, err2 := b.Book(slot.ID, "alice@example.com", 1)
+			_, err3 := b.Book(slot.ID, "bob@example.com", 2)
+			_, err4 := b.Book(slot.ID, "carol@example.com", 1)
+			slots, lerr := b.ListSlotsByService(svc.ID, services.SlotFilter{})
+			return []any{errString(overlap), errString(err1), errString(err2), errString(err3), errString(err4), slots, errString(lerr)}
+		}},
+		{"waitlist promotion", func(t *testing.T, b *services.BookingService) any {
+			svc, err := b.CreateService(services.Service{Name: "Yoga", Duration: 60})
+			if err != nil {
+				t.Fatal(err)
+			}
+			slot, err := b.AddSlot(svc.ID, at(1, 0), 1, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := b.Book(slot.ID, "alice@example.com", 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, pos, werr := b.BookOrWait(slot.ID, "bob@example.com", 1)
+			waiting, _ := b.MyWaitlist("bob@example.com")
+			cerr := b.Cancel(res.ID, "alice@example.com")
+			alice, _ := b.MyReservations("alice@example.com")
+			bob, _ := b.MyReservations("bob@example.com")
+			after, _ := b.MyWaitlist("bob@example.com")
+			return []any{pos, errString(werr), waiting, errString(cerr), alice, bob, after}
+		}},
+		{"reschedule and archive", func(t *testing.T, b *services.BookingService) any {
+			svc, err := b.CreateService(services.Service{Name: "Yoga", Duration: 60})
+			if err != nil {
+				t.Fatal(err)
+			}
+			first, err := b.AddSlot(svc.ID, at(1, 0), 1, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := b.AddSlot(svc.ID, at(2, 0), 1, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := b.Book(first.ID, "alice@example.com", 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			moved, merr := b.Reschedule(res.ID, second.ID, "alice@example.com")
+			_, inUse := b.DeleteSlot(second.ID, false)
+			cancelled, derr := b.DeleteSlot(second.ID, true)
+			mine, _ := b.MyReservations("alice@example.com")
+			slots, _ := b.ListSlotsByService(svc.ID, services.SlotFilter{})
+			return []any{moved, errString(merr), errString(inUse), cancelled, errString(derr), mine, slots}
+		}},
+		{"holds", func(t *testing.T, b *services.BookingService) any {
+			svc, err := b.CreateService(services.Service{Name: "Yoga", Duration: 60})
+			if err != nil {
+				t.Fatal(err)
+			}
+			slot, err := b.AddSlot(svc.ID, at(1, 0), 1, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hold, herr := b.HoldSeats(slot.ID, "alice@example.com", 1)
+			_, full := b.Book(slot.ID, "bob@example.com", 1)
+			res, cerr := b.ConfirmHold(hold.ID, "alice@example.com")
+			_, gone := b.ConfirmHold(hold.ID, "alice@example.com")
+			return []any{hold, errString(herr), errString(full), res, errString(cerr), errString(gone)}
+		}},
+		{"recurring series and availability", func(t *testing.T, b *services.BookingService) any {
+			svc, err := b.CreateService(services.Service{Name: "Yoga", Duration: 60})
+			if err != nil {
+				t.Fatal(err)
+			}
+			slots, skipped, rerr := b.AddRecurringSlots(svc.ID, services.RecurrenceSpec{
+				Start:    at(0, 1),
+				RRule:    "FREQ=DAILY;COUNT=4",
+				Capacity: 2,
+			})
+			if rerr != nil {
+				t.Fatal(rerr)
+			}
+			if _, err := b.Book(slots[0].ID, "alice@example.com", 2); err != nil {
+				t.Fatal(err)
+			}
+			_, derr := b.DeleteSeries(slots[2].ID, true)
+			avail, aerr := b.SearchAvailability(services.AvailabilityQuery{From: at(0, 0), To: at(7, 0)})
+			return []any{slots, skipped, errString(derr), avail, errString(aerr)}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonRepo, err := repository.NewJSONStore(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			sqlRepo, err := repository.NewSQLStore(filepath.Join(t.TempDir(), "test.db"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sqlRepo.Close()
+
+			var got [2]string
+			for i, repo := range []services.Repository{jsonRepo, sqlRepo} {
+				b := services.NewBookingService(repo, services.WithClock(func() time.Time { return start }))
+				got[i] = snapshot(t, tt.run(t, b))
+			}
+			if got[0] != got[1] {
+				t.Errorf("stores disagree\njson: %s\nsql:  %s", got[0], got[1])
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"gestionsvc/internal/services"
+)
+
+// Une réservation déplacée reçoit les rappels de son nouvel horaire,
+// sans doublon pour l'ancien comme pour le nouveau.
+func TestReminderAfterReschedule(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repo services.Repository) {
+		first := time.Date(2099, 1, 5, 9, 0, 0, 0, time.UTC)
+		second := first.AddDate(0, 0, 1)
+		now := first.Add(-2 * time.Hour)
+		b := services.NewBookingService(repo, services.WithClock(func() time.Time { return now }))
+		offsets := []time.Duration{time.Hour}
+
+		svc, err := b.CreateService(services.Service{Name: "Yoga", Duration: 60})
+		if err != nil {
+			t.Fatal(err)
+		}
+		slotA, err := b.AddSlot(svc.ID, first.Format(time.RFC3339), 1, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		slotB, err := b.AddSlot(svc.ID, second.Format(time.RFC3339), 1, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := b.Book(slotA.ID, "alice@example.com", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		send := func(want int) {
+			t.Helper()
+			n, err := b.SendDueReminders(offsets)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != want {
+				t.Fatalf("SendDueReminders at %s = %d, want %d", now, n, want)
+			}
+		}
+
+		now = first.Add(-30 * time.Minute)
+		send(1)
+		send(0)
+
+		if _, err := b.Reschedule(res.ID, slotB.ID, "alice@example.com"); err != nil {
+			t.Fatal(err)
+		}
+		send(0)
+
+		now = second.Add(-30 * time.Minute)
+		send(1)
+		send(0)
+	})
+}
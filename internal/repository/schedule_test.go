@@ -0,0 +1,64 @@
+package repository_test
+
+import (
+	"errors"
+	"testing"
+
+	"gestionsvc/internal/services"
+)
+
+// Allonger un service revérifie ses créneaux à venir : chevauchements
+// (NoOverlap) et ressources partagées avec d'autres services.
+func TestLongerServiceRechecksSlots(t *testing.T) {
+	forEachStore(t, func(t *testing.T, repo services.Repository) {
+		b := services.NewBookingService(repo)
+		longer := 90
+
+		yoga, err := b.CreateService(services.Service{Name: "Yoga", Duration: 60, NoOverlap: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, at := range []string{"2099-01-05T09:00:00Z", "2099-01-05T10:00:00Z"} {
+			if _, err := b.AddSlot(yoga.ID, at, 1, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := b.UpdateService(yoga.ID, services.ServiceUpdate{Duration: &longer}); !errors.Is(err, services.ErrSlotOverlap) {
+			t.Fatalf("longer NoOverlap service: got %v, want ErrSlotOverlap", err)
+		}
+
+		room, err := b.CreateResource(services.Resource{Name: "Salle 1", Kind: services.ResourceRoom})
+		if err != nil {
+			t.Fatal(err)
+		}
+		massage, err := b.CreateService(services.Service{Name: "Massage", Duration: 60})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pilates, err := b.CreateService(services.Service{Name: "Pilates", Duration: 60})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.AddSlot(massage.ID, "2099-01-06T09:00:00Z", 1, []services.ID{room.ID}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.AddSlot(pilates.ID, "2099-01-06T10:00:00Z", 1, []services.ID{room.ID}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := b.UpdateService(massage.ID, services.ServiceUpdate{Duration: &longer}); !errors.Is(err, services.ErrResourceBusy) {
+			t.Fatalf("longer service on a shared room: got %v, want ErrResourceBusy", err)
+		}
+
+		shorter := 45
+		if _, err := b.UpdateService(massage.ID, services.ServiceUpdate{Duration: &shorter}); err != nil {
+			t.Fatalf("shorter service: %v", err)
+		}
+		svc, err := repo.GetService(massage.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if svc.Duration != shorter {
+			t.Fatalf("duration = %d, want %d", svc.Duration, shorter)
+		}
+	})
+}
@@ -0,0 +1,1479 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	// Driver SQLite en Go pur (pas de cgo) : la base est embarquée dans un simple fichier.
+	_ "modernc.org/sqlite"
+
+	"gestionsvc/internal/services"
+)
+
+//
+// ---------- Schéma SQL ----------
+//
+
+// sqlSchema crée les tables si elles n'existent pas encore.
+//
+// Les clés étrangères garantissent qu'un slot appartient à un service existant
+// et qu'une réservation pointe vers un slot existant.
+// Les index accélèrent les recherches faites par le BookingService
+// (slots d'un service, réservations d'un slot ou d'un utilisateur).
+const sqlSchema = `
+CREATE TABLE IF NOT EXISTS services (
+	id          TEXT PRIMARY KEY,
+	name        TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	duration    INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS slots (
+	id         TEXT PRIMARY KEY,
+	service_id TEXT NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+	datetime   TEXT NOT NULL,
+	capacity   INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_slots_service_id ON slots(service_id);
+
+CREATE TABLE IF NOT EXISTS reservations (
+	id         TEXT PRIMARY KEY,
+	slot_id    TEXT NOT NULL REFERENCES slots(id) ON DELETE CASCADE,
+	user_email TEXT NOT NULL,
+	created_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_reservations_slot_id ON reservations(slot_id);
+CREATE INDEX IF NOT EXISTS idx_reservations_user_email ON reservations(user_email);
+
+CREATE TABLE IF NOT EXISTS users (
+	email         TEXT PRIMARY KEY,
+	password_hash TEXT NOT NULL DEFAULT '',
+	created_at    TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	id         TEXT PRIMARY KEY,
+	user_email TEXT NOT NULL REFERENCES users(email) ON DELETE CASCADE,
+	created_at TEXT NOT NULL,
+	expires_at TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions(expires_at);
+`
+
+// sqlMigrations = modifications du schéma appliquées aux bases existantes,
+// dans l'ordre. PRAGMA user_version mémorise combien ont déjà été appliquées :
+// on ajoute toujours les nouvelles migrations à la fin, sans modifier les anciennes.
+var sqlMigrations = []string{
+	// 1 : rôles des comptes et propriétaire des services
+	`ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'customer';
+	 ALTER TABLE services ADD COLUMN owner TEXT NOT NULL DEFAULT '';`,
+
+	// 2 : liens de connexion à usage unique
+	`CREATE TABLE login_links (
+		id         TEXT PRIMARY KEY,
+		email      TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		expires_at TEXT NOT NULL
+	);
+	CREATE INDEX idx_login_links_expires_at ON login_links(expires_at);`,
+
+	// 3 : rappels envoyés + recherche des créneaux par date
+	`CREATE TABLE reminders (
+		reservation_id TEXT NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+		offset_minutes INTEGER NOT NULL,
+		sent_at        TEXT NOT NULL,
+		PRIMARY KEY (reservation_id, offset_minutes)
+	);
+	CREATE INDEX idx_slots_datetime ON slots(datetime);`,
+
+	// 4 : liste d'attente des créneaux complets
+	`CREATE TABLE waitlist (
+		id         TEXT PRIMARY KEY,
+		slot_id    TEXT NOT NULL REFERENCES slots(id) ON DELETE CASCADE,
+		user_email TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		UNIQUE (slot_id, user_email)
+	);
+	CREATE INDEX idx_waitlist_user_email ON waitlist(user_email);`,
+
+	// 5 : places retenues pendant la saisie d'une réservation
+	`CREATE TABLE holds (
+		id         TEXT PRIMARY KEY,
+		slot_id    TEXT NOT NULL REFERENCES slots(id) ON DELETE CASCADE,
+		user_email TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		expires_at TEXT NOT NULL
+	);
+	CREATE INDEX idx_holds_slot_id ON holds(slot_id);
+	CREATE INDEX idx_holds_expires_at ON holds(expires_at);`,
+
+	// 6 : réservations de groupe (nombre de places) et taille maximale par service
+	`ALTER TABLE reservations ADD COLUMN seats INTEGER NOT NULL DEFAULT 1;
+	 ALTER TABLE waitlist ADD COLUMN seats INTEGER NOT NULL DEFAULT 1;
+	 ALTER TABLE holds ADD COLUMN seats INTEGER NOT NULL DEFAULT 1;
+	 ALTER TABLE services ADD COLUMN max_party_size INTEGER NOT NULL DEFAULT 0;`,
+
+	// 7 : statut des réservations (les annulations sont conservées) ;
+	// services et créneaux archivés au lieu d'être supprimés
+	`ALTER TABLE reservations ADD COLUMN status TEXT NOT NULL DEFAULT 'confirmed';
+	 ALTER TABLE reservations ADD COLUMN confirmed_at TEXT;
+	 ALTER TABLE reservations ADD COLUMN cancelled_at TEXT;
+	 ALTER TABLE reservations ADD COLUMN attended_at TEXT;
+	 ALTER TABLE reservations ADD COLUMN no_show_at TEXT;
+	 ALTER TABLE services ADD COLUMN deleted_at TEXT;
+	 ALTER TABLE slots ADD COLUMN deleted_at TEXT;`,
+
+	// 8 : rappels envoyés par date de créneau (une réservation déplacée en reçoit de nouveaux)
+	`CREATE TABLE reminders_v8 (
+		reservation_id TEXT NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+		slot_datetime  TEXT NOT NULL DEFAULT '',
+		offset_minutes INTEGER NOT NULL,
+		sent_at        TEXT NOT NULL,
+		PRIMARY KEY (reservation_id, slot_datetime, offset_minutes)
+	);
+	INSERT INTO reminders_v8 (reservation_id, offset_minutes, sent_at)
+		SELECT reservation_id, offset_minutes, sent_at FROM reminders;
+	DROP TABLE reminders;
+	ALTER TABLE reminders_v8 RENAME TO reminders;`,
+
+	// 9 : politique d'annulation des services, déplacements et annulations tardives
+	`ALTER TABLE services ADD COLUMN cancel_notice_minutes INTEGER NOT NULL DEFAULT 0;
+	 ALTER TABLE services ADD COLUMN max_reschedules INTEGER NOT NULL DEFAULT 0;
+	 ALTER TABLE services ADD COLUMN allow_late_cancel INTEGER NOT NULL DEFAULT 0;
+	 ALTER TABLE reservations ADD COLUMN reschedules INTEGER NOT NULL DEFAULT 0;
+	 ALTER TABLE reservations ADD COLUMN late_cancel INTEGER NOT NULL DEFAULT 0;`,
+
+	// 10 : séries de créneaux récurrents
+	`ALTER TABLE slots ADD COLUMN series_id TEXT NOT NULL DEFAULT '';
+	 CREATE INDEX idx_slots_series_id ON slots(series_id);`,
+
+	// 11 : créneaux sans chevauchement par service
+	`ALTER TABLE services ADD COLUMN no_overlap INTEGER NOT NULL DEFAULT 0;`,
+
+	// 12 : ressources (personnel, salles, équipements) mobilisées par les créneaux
+	`CREATE TABLE resources (
+		id   TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		kind TEXT NOT NULL
+	);
+	CREATE TABLE slot_resources (
+		slot_id     TEXT NOT NULL REFERENCES slots(id) ON DELETE CASCADE,
+		resource_id TEXT NOT NULL REFERENCES resources(id),
+		PRIMARY KEY (slot_id, resource_id)
+	);
+	CREATE INDEX idx_slot_resources_resource_id ON slot_resources(resource_id);`,
+
+	// 13 : horaires d'ouverture et fermetures des services (JSON, '' = aucun)
+	`ALTER TABLE services ADD COLUMN calendar TEXT NOT NULL DEFAULT '';`,
+
+	// 14 : fuseaux horaires (IANA) des services et des créneaux ('' = fuseau par défaut)
+	`ALTER TABLE services ADD COLUMN time_zone TEXT NOT NULL DEFAULT '';
+	 ALTER TABLE slots ADD COLUMN time_zone TEXT NOT NULL DEFAULT '';`,
+
+	// 15 : places retenues par utilisateur (limites de réservation)
+	`CREATE INDEX idx_holds_user_email ON holds(user_email);`,
+}
+
+// migrate applique les migrations qui manquent à la base, chacune dans
+// sa propre transaction avec la mise à jour de user_version.
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+
+	for i := version; i < len(sqlMigrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqlMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//
+// ---------- Helpers ----------
+//
+
+// sqlTimeLayout = RFC3339 en UTC avec un nombre fixe de décimales :
+// l'ordre alphabétique des textes est alors l'ordre chronologique.
+const sqlTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// formatTime convertit une date en texte triable.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(sqlTimeLayout)
+}
+
+// foreignKeyError remplace une violation de clé étrangère SQLite
+// par l'erreur métier correspondante (ex : service inexistant).
+func foreignKeyError(err error, missing error) error {
+	if err != nil && strings.Contains(err.Error(), "FOREIGN KEY constraint failed") {
+		return missing
+	}
+	return err
+}
+
+// parseTime relit une date stockée par formatTime.
+func parseTime(s string) (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// formatNullTime convertit une date facultative (nil → NULL).
+func formatNullTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return formatTime(*t)
+}
+
+// parseNullTime relit une date facultative stockée par formatNullTime.
+func parseNullTime(s sql.NullString) (*time.Time, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	t, err := parseTime(s.String)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+//
+// ---------- SQLStore : implémentation SQLite du Repository ----------
+//
+
+// queryer est satisfait par *sql.DB et *sql.Tx : les mêmes requêtes
+// servent hors transaction et dans une transaction.
+type queryer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// sqlRepo contient toutes les requêtes du Repository.
+type sqlRepo struct {
+	q queryer
+}
+
+// SQLStore stocke les données dans une base SQLite embarquée.
+//
+// Contrairement à JSONStore, chaque modification n'écrit que la ligne concernée,
+// et SQLite gère lui-même les accès concurrents (WAL + busy_timeout).
+type SQLStore struct {
+	sqlRepo
+	db *sql.DB
+}
+
+// sqlTx est le Repository passé aux fonctions exécutées par WithTx.
+type sqlTx struct {
+	sqlRepo
+}
+
+// NewSQLStore ouvre (ou crée) la base SQLite située à path et applique le schéma.
+func NewSQLStore(path string) (*SQLStore, error) {
+	dsn := "file:" + path +
+		"?_pragma=foreign_keys(1)" +
+		"&_pragma=journal_mode(WAL)" +
+		"&_pragma=busy_timeout(5000)" +
+		"&_txlock=immediate"
+
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := db.Exec(sqlSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite schema: %w", err)
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite schema: %w", err)
+	}
+
+	return &SQLStore{sqlRepo: sqlRepo{q: db}, db: db}, nil
+}
+
+// Close ferme la connexion à la base.
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+//
+// ---------- Transactions ----------
+//
+
+// WithTx exécute fn dans une transaction SQLite.
+//
+// Grâce à _txlock=immediate, la transaction prend le verrou d'écriture dès
+// son ouverture : deux réservations concurrentes sont sérialisées et la
+// seconde voit la première. La transaction est annulée si fn renvoie une erreur.
+func (s *SQLStore) WithTx(fn func(tx services.Repository) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(&sqlTx{sqlRepo{q: tx}}); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// WithTx dans une transaction déjà ouverte : on réutilise la même.
+func (t *sqlTx) WithTx(fn func(tx services.Repository) error) error {
+	return fn(t)
+}
+
+//
+// ---------- Services ----------
+//
+
+// serviceColumns = colonnes lues par scanService, dans l'ordre.
+const serviceColumns = `id, name, description, duration, owner, max_party_size,
+	cancel_notice_minutes, max_reschedules, allow_late_cancel, no_overlap, calendar, time_zone`
+
+// scanService lit une ligne de la table services (serviceColumns).
+func scanService(sc scanner) (services.Service, error) {
+	var (
+		svc      services.Service
+		calendar string
+	)
+	err := sc.Scan(&svc.ID, &svc.Name, &svc.Description, &svc.Duration, &svc.Owner, &svc.MaxPartySize,
+		&svc.Cancellation.NoticeMinutes, &svc.Cancellation.MaxReschedules, &svc.Cancellation.AllowLate,
+		&svc.NoOverlap, &calendar, &svc.TimeZone)
+	if err != nil {
+		return services.Service{}, err
+	}
+	if calendar != "" {
+		svc.Calendar = new(services.Calendar)
+		if err := json.Unmarshal([]byte(calendar), svc.Calendar); err != nil {
+			return services.Service{}, fmt.Errorf("service %s calendar: %w", svc.ID, err)
+		}
+	}
+	return svc, nil
+}
+
+// formatCalendar encode le calendrier d'un service pour la colonne calendar.
+func formatCalendar(c *services.Calendar) (string, error) {
+	if c.IsZero() {
+		return "", nil
+	}
+	b, err := json.Marshal(c)
+	return string(b), err
+}
+
+// ListServices renvoie la liste des services non archivés.
+func (s sqlRepo) ListServices() ([]services.Service, error) {
+	rows, err := s.q.Query(`SELECT ` + serviceColumns + ` FROM services WHERE deleted_at IS NULL ORDER BY rowid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []services.Service
+	for rows.Next() {
+		svc, err := scanService(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, svc)
+	}
+	return out, rows.Err()
+}
+
+// CreateService ajoute un nouveau service.
+func (s sqlRepo) CreateService(svc services.Service) (services.Service, error) {
+	if svc.ID == "" {
+		svc.ID = newID("svc")
+	}
+	calendar, err := formatCalendar(svc.Calendar)
+	if err != nil {
+		return services.Service{}, err
+	}
+
+	_, err = s.q.Exec(
+		`INSERT INTO services (`+serviceColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		svc.ID, svc.Name, svc.Description, svc.Duration, svc.Owner, svc.MaxPartySize,
+		svc.Cancellation.NoticeMinutes, svc.Cancellation.MaxReschedules, svc.Cancellation.AllowLate,
+		svc.NoOverlap, calendar, svc.TimeZone,
+	)
+	if err != nil {
+		return services.Service{}, err
+	}
+
+	return svc, nil
+}
+
+// GetService retourne un service (non archivé) selon son ID.
+func (s sqlRepo) GetService(serviceID services.ID) (services.Service, error) {
+	svc, err := scanService(s.q.QueryRow(
+		`SELECT `+serviceColumns+` FROM services WHERE id = ? AND deleted_at IS NULL`,
+		serviceID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return services.Service{}, services.ErrServiceNotFound
+	}
+	if err != nil {
+		return services.Service{}, err
+	}
+	return svc, nil
+}
+
+// UpdateService remplace un service existant.
+func (s sqlRepo) UpdateService(svc services.Service) (services.Service, error) {
+	calendar, err := formatCalendar(svc.Calendar)
+	if err != nil {
+		return services.Service{}, err
+	}
+
+	res, err := s.q.Exec(
+		`UPDATE services SET name = ?, description = ?, duration = ?, owner = ?, max_party_size = ?,
+		 cancel_notice_minutes = ?, max_reschedules = ?, allow_late_cancel = ?, no_overlap = ?, calendar = ?,
+		 time_zone = ?
+		 WHERE id = ? AND deleted_at IS NULL`,
+		svc.Name, svc.Description, svc.Duration, svc.Owner, svc.MaxPartySize,
+		svc.Cancellation.NoticeMinutes, svc.Cancellation.MaxReschedules, svc.Cancellation.AllowLate,
+		svc.NoOverlap, calendar, svc.TimeZone, svc.ID,
+	)
+	if err := checkAffected(res, err, services.ErrServiceNotFound); err != nil {
+		return services.Service{}, err
+	}
+	return svc, nil
+}
+
+// DeleteService archive un service et ses slots ; leurs réservations
+// sont conservées (historique).
+func (s sqlRepo) DeleteService(serviceID services.ID, at time.Time) error {
+	res, err := s.q.Exec(
+		`UPDATE services SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		formatTime(at), serviceID,
+	)
+	if err := checkAffected(res, err, services.ErrServiceNotFound); err != nil {
+		return err
+	}
+	_, err = s.archiveSlots(at, `service_id = ?`, serviceID)
+	return err
+}
+
+// checkAffected renvoie notFound si la requête n'a modifié aucune ligne.
+func checkAffected(res sql.Result, err error, notFound error) error {
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+//
+// ---------- Slots ----------
+//
+
+// AddSlot ajoute un créneau horaire à un service non archivé.
+func (s sqlRepo) AddSlot(slot services.Slot) (services.Slot, error) {
+	if _, err := s.GetService(slot.ServiceID); err != nil {
+		return services.Slot{}, err
+	}
+	if slot.ID == "" {
+		slot.ID = newID("slt")
+	}
+
+	_, err := s.q.Exec(
+		`INSERT INTO slots (id, service_id, datetime, capacity, series_id, time_zone) VALUES (?, ?, ?, ?, ?, ?)`,
+		slot.ID, slot.ServiceID, formatTime(slot.Datetime), slot.Capacity, slot.SeriesID, slot.TimeZone,
+	)
+	if err != nil {
+		return services.Slot{}, foreignKeyError(err, services.ErrServiceNotFound)
+	}
+	if err := s.setSlotResources(slot); err != nil {
+		return services.Slot{}, err
+	}
+
+	return slot, nil
+}
+
+// ListSlotsByService retourne les créneaux d'un service, triés par date.
+func (s sqlRepo) ListSlotsByService(serviceID services.ID) ([]services.Slot, error) {
+	rows, err := s.q.Query(
+		`SELECT `+slotColumns+` FROM slots WHERE service_id = ? AND deleted_at IS NULL ORDER BY datetime`,
+		serviceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []services.Slot
+	for rows.Next() {
+		sl, err := scanSlot(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sl)
+	}
+	return out, rows.Err()
+}
+
+// ListSlotsByResource retourne les créneaux qui mobilisent une ressource, par date.
+func (s sqlRepo) ListSlotsByResource(resourceID services.ID) ([]services.Slot, error) {
+	rows, err := s.q.Query(
+		`SELECT `+slotColumns+` FROM slots
+		 WHERE id IN (SELECT slot_id FROM slot_resources WHERE resource_id = ?) AND deleted_at IS NULL
+		 ORDER BY datetime`,
+		resourceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []services.Slot
+	for rows.Next() {
+		sl, err := scanSlot(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sl)
+	}
+	return out, rows.Err()
+}
+
+// GetSlot retourne un slot (non archivé) selon son ID.
+func (s sqlRepo) GetSlot(slotID services.ID) (services.Slot, error) {
+	row := s.q.QueryRow(
+		`SELECT `+slotColumns+` FROM slots WHERE id = ? AND deleted_at IS NULL`,
+		slotID,
+	)
+
+	sl, err := scanSlot(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return services.Slot{}, services.ErrSlotNotFound
+	}
+	return sl, err
+}
+
+// UpdateSlot remplace un créneau existant.
+func (s sqlRepo) UpdateSlot(slot services.Slot) (services.Slot, error) {
+	res, err := s.q.Exec(
+		`UPDATE slots SET service_id = ?, datetime = ?, capacity = ?, series_id = ?, time_zone = ?
+		 WHERE id = ? AND deleted_at IS NULL`,
+		slot.ServiceID, formatTime(slot.Datetime), slot.Capacity, slot.SeriesID, slot.TimeZone, slot.ID,
+	)
+	if err := checkAffected(res, foreignKeyError(err, services.ErrServiceNotFound), services.ErrSlotNotFound); err != nil {
+		return services.Slot{}, err
+	}
+	if err := s.setSlotResources(slot); err != nil {
+		return services.Slot{}, err
+	}
+	return slot, nil
+}
+
+// setSlotResources remplace les ressources d'un créneau (table slot_resources).
+func (s sqlRepo) setSlotResources(slot services.Slot) error {
+	if _, err := s.q.Exec(`DELETE FROM slot_resources WHERE slot_id = ?`, slot.ID); err != nil {
+		return err
+	}
+	for _, id := range slot.Resources {
+		_, err := s.q.Exec(
+			`INSERT INTO slot_resources (slot_id, resource_id) VALUES (?, ?)`,
+			slot.ID, id,
+		)
+		if err != nil {
+			return foreignKeyError(err, services.ErrResourceNotFound)
+		}
+	}
+	return nil
+}
+
+// DeleteSlot archive un créneau ; ses réservations sont conservées.
+func (s sqlRepo) DeleteSlot(slotID services.ID, at time.Time) error {
+	n, err := s.archiveSlots(at, `id = ?`, slotID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return services.ErrSlotNotFound
+	}
+	return nil
+}
+
+// archiveSlots archive à la date at les créneaux non archivés qui
+// vérifient where (ex : "id = ?") et renvoie leur nombre. Leurs réservations
+// restent ; leurs listes d'attente, places retenues, ressources et rappels
+// envoyés sont supprimés.
+func (s sqlRepo) archiveSlots(at time.Time, where string, args ...any) (int64, error) {
+	if _, err := s.q.Exec(
+		`DELETE FROM reminders WHERE reservation_id IN (SELECT id FROM reservations
+		 WHERE slot_id IN (SELECT id FROM slots WHERE deleted_at IS NULL AND `+where+`))`,
+		args...,
+	); err != nil {
+		return 0, err
+	}
+	for _, table := range []string{"waitlist", "holds", "slot_resources"} {
+		if _, err := s.q.Exec(
+			`DELETE FROM `+table+` WHERE slot_id IN (SELECT id FROM slots WHERE deleted_at IS NULL AND `+where+`)`,
+			args...,
+		); err != nil {
+			return 0, err
+		}
+	}
+
+	res, err := s.q.Exec(
+		`UPDATE slots SET deleted_at = ? WHERE deleted_at IS NULL AND `+where,
+		append([]any{formatTime(at)}, args...)...,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// scanner est satisfait par *sql.Row et *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// slotColumns = colonnes lues par scanSlot, dans l'ordre ; les ressources
+// du créneau sont regroupées en une seule valeur ("rsc_1,rsc_2").
+const slotColumns = `id, service_id, datetime, capacity, series_id, time_zone,
+	(SELECT group_concat(resource_id) FROM slot_resources WHERE slot_id = slots.id)`
+
+// scanSlot lit une ligne de la table slots (slotColumns).
+func scanSlot(sc scanner) (services.Slot, error) {
+	var (
+		sl        services.Slot
+		dt        string
+		resources sql.NullString
+	)
+	if err := sc.Scan(&sl.ID, &sl.ServiceID, &dt, &sl.Capacity, &sl.SeriesID, &sl.TimeZone, &resources); err != nil {
+		return services.Slot{}, err
+	}
+
+	t, err := parseTime(dt)
+	if err != nil {
+		return services.Slot{}, err
+	}
+	sl.Datetime = t
+
+	if resources.Valid {
+		ids := strings.Split(resources.String, ",")
+		sort.Strings(ids)
+		for _, id := range ids {
+			sl.Resources = append(sl.Resources, services.ID(id))
+		}
+	}
+
+	return sl, nil
+}
+
+//
+// ---------- Ressources ----------
+//
+
+// ListResources renvoie la liste des ressources.
+func (s sqlRepo) ListResources() ([]services.Resource, error) {
+	rows, err := s.q.Query(`SELECT id, name, kind FROM resources ORDER BY rowid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []services.Resource
+	for rows.Next() {
+		var r services.Resource
+		if err := rows.Scan(&r.ID, &r.Name, &r.Kind); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// GetResource retourne une ressource selon son ID.
+func (s sqlRepo) GetResource(id services.ID) (services.Resource, error) {
+	var r services.Resource
+	err := s.q.QueryRow(`SELECT id, name, kind FROM resources WHERE id = ?`, id).
+		Scan(&r.ID, &r.Name, &r.Kind)
+	if errors.Is(err, sql.ErrNoRows) {
+		return services.Resource{}, services.ErrResourceNotFound
+	}
+	return r, err
+}
+
+// CreateResource ajoute une ressource.
+func (s sqlRepo) CreateResource(r services.Resource) (services.Resource, error) {
+	if r.ID == "" {
+		r.ID = newID("rsc")
+	}
+
+	_, err := s.q.Exec(
+		`INSERT INTO resources (id, name, kind) VALUES (?, ?, ?)`,
+		r.ID, r.Name, r.Kind,
+	)
+	if err != nil {
+		return services.Resource{}, err
+	}
+	return r, nil
+}
+
+// UpdateResource remplace une ressource existante.
+func (s sqlRepo) UpdateResource(r services.Resource) (services.Resource, error) {
+	res, err := s.q.Exec(
+		`UPDATE resources SET name = ?, kind = ? WHERE id = ?`,
+		r.Name, r.Kind, r.ID,
+	)
+	if err := checkAffected(res, err, services.ErrResourceNotFound); err != nil {
+		return services.Resource{}, err
+	}
+	return r, nil
+}
+
+// DeleteResource supprime une ressource ; la clé étrangère refuse la
+// suppression tant qu'un créneau l'utilise.
+func (s sqlRepo) DeleteResource(id services.ID) error {
+	res, err := s.q.Exec(`DELETE FROM resources WHERE id = ?`, id)
+	return checkAffected(res, foreignKeyError(err, services.ErrResourceInUse), services.ErrResourceNotFound)
+}
+
+//
+// ---------- Reservations ----------
+//
+
+// CreateReservation enregistre une réservation.
+func (s sqlRepo) CreateReservation(r services.Reservation) (services.Reservation, error) {
+	if r.ID == "" {
+		r.ID = newID("res")
+	}
+
+	_, err := s.q.Exec(
+		`INSERT INTO reservations (`+reservationColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		r.ID, r.SlotID, r.UserEmail, r.Seats, r.Status.OrDefault(), formatTime(r.CreatedAt),
+		formatNullTime(r.ConfirmedAt), formatNullTime(r.CancelledAt),
+		formatNullTime(r.AttendedAt), formatNullTime(r.NoShowAt),
+		r.Reschedules, r.LateCancel,
+	)
+	if err != nil {
+		return services.Reservation{}, foreignKeyError(err, services.ErrSlotNotFound)
+	}
+
+	return r, nil
+}
+
+// ListReservationsByEmail recherche toutes les réservations d'un utilisateur.
+func (s sqlRepo) ListReservationsByEmail(email string) ([]services.Reservation, error) {
+	return s.queryReservations(
+		`SELECT `+reservationColumns+` FROM reservations WHERE user_email = ? ORDER BY created_at`,
+		email,
+	)
+}
+
+// ListReservationsBySlot retourne les réservations d'un créneau donné.
+func (s sqlRepo) ListReservationsBySlot(slotID services.ID) ([]services.Reservation, error) {
+	return s.queryReservations(
+		`SELECT `+reservationColumns+` FROM reservations WHERE slot_id = ? ORDER BY created_at`,
+		slotID,
+	)
+}
+
+// countSeatsBatch = nombre maximum de créneaux par requête de CountSeats
+// (limite des paramètres SQLite).
+const countSeatsBatch = 500
+
+// CountSeats compte les places occupées de plusieurs créneaux : une requête
+// groupée sur les réservations et une sur les places retenues (par lot de
+// countSeatsBatch créneaux).
+func (s sqlRepo) CountSeats(slotIDs []services.ID, now time.Time) (map[services.ID]services.SeatUsage, error) {
+	out := map[services.ID]services.SeatUsage{}
+	for start := 0; start < len(slotIDs); start += countSeatsBatch {
+		batch := slotIDs[start:min(start+countSeatsBatch, len(slotIDs))]
+
+		in := strings.TrimSuffix(strings.Repeat("?, ", len(batch)), ", ")
+		args := make([]any, 0, len(batch)+1)
+		for _, id := range batch {
+			args = append(args, id)
+		}
+
+		err := s.sumSeats(
+			`SELECT slot_id, SUM(MAX(seats, 1)) FROM reservations
+			 WHERE status != 'cancelled' AND slot_id IN (`+in+`) GROUP BY slot_id`,
+			args, func(u *services.SeatUsage, n int) { u.Booked = n }, out)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.sumSeats(
+			`SELECT slot_id, SUM(MAX(seats, 1)) FROM holds
+			 WHERE expires_at > ? AND slot_id IN (`+in+`) GROUP BY slot_id`,
+			append([]any{formatTime(now)}, args...), func(u *services.SeatUsage, n int) { u.Held = n }, out)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// sumSeats exécute une requête (slot_id, total) et range chaque total dans out avec set.
+func (s sqlRepo) sumSeats(query string, args []any, set func(*services.SeatUsage, int), out map[services.ID]services.SeatUsage) error {
+	rows, err := s.q.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id services.ID
+			n  int
+		)
+		if err := rows.Scan(&id, &n); err != nil {
+			return err
+		}
+		u := out[id]
+		set(&u, n)
+		out[id] = u
+	}
+	return rows.Err()
+}
+
+// GetReservation récupère une réservation par ID.
+func (s sqlRepo) GetReservation(resID services.ID) (services.Reservation, error) {
+	row := s.q.QueryRow(
+		`SELECT `+reservationColumns+` FROM reservations WHERE id = ?`,
+		resID,
+	)
+
+	r, err := scanReservation(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return services.Reservation{}, services.ErrReservationNotFound
+	}
+	return r, err
+}
+
+// UpdateReservation enregistre les champs modifiables d'une réservation
+// (créneau, nombre de places, statut et dates des changements de statut,
+// nombre de déplacements, annulation tardive).
+func (s sqlRepo) UpdateReservation(r services.Reservation) (services.Reservation, error) {
+	res, err := s.q.Exec(
+		`UPDATE reservations SET slot_id = ?, seats = ?, status = ?, confirmed_at = ?, cancelled_at = ?, attended_at = ?, no_show_at = ?,
+		 reschedules = ?, late_cancel = ?
+		 WHERE id = ?`,
+		r.SlotID, r.Seats, r.Status.OrDefault(), formatNullTime(r.ConfirmedAt), formatNullTime(r.CancelledAt),
+		formatNullTime(r.AttendedAt), formatNullTime(r.NoShowAt), r.Reschedules, r.LateCancel, r.ID,
+	)
+	if err := checkAffected(res, foreignKeyError(err, services.ErrSlotNotFound), services.ErrReservationNotFound); err != nil {
+		return services.Reservation{}, err
+	}
+	return r, nil
+}
+
+// DeleteReservation supprime une réservation si elle existe.
+func (s sqlRepo) DeleteReservation(resID services.ID) error {
+	res, err := s.q.Exec(`DELETE FROM reservations WHERE id = ?`, resID)
+	return checkAffected(res, err, services.ErrReservationNotFound)
+}
+
+// queryReservations exécute une requête renvoyant des lignes de la table reservations.
+func (s sqlRepo) queryReservations(query string, args ...any) ([]services.Reservation, error) {
+	rows, err := s.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []services.Reservation
+	for rows.Next() {
+		r, err := scanReservation(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// reservationColumns = colonnes lues par scanReservation, dans l'ordre.
+const reservationColumns = `id, slot_id, user_email, seats, status, created_at,
+	confirmed_at, cancelled_at, attended_at, no_show_at, reschedules, late_cancel`
+
+// scanReservation lit une ligne de la table reservations (reservationColumns).
+func scanReservation(sc scanner) (services.Reservation, error) {
+	var (
+		r                                      services.Reservation
+		created                                string
+		confirmed, cancelled, attended, noShow sql.NullString
+	)
+	if err := sc.Scan(&r.ID, &r.SlotID, &r.UserEmail, &r.Seats, &r.Status, &created,
+		&confirmed, &cancelled, &attended, &noShow, &r.Reschedules, &r.LateCancel); err != nil {
+		return services.Reservation{}, err
+	}
+
+	t, err := parseTime(created)
+	if err != nil {
+		return services.Reservation{}, err
+	}
+	r.CreatedAt = t
+
+	for _, f := range []struct {
+		src sql.NullString
+		dst **time.Time
+	}{
+		{confirmed, &r.ConfirmedAt},
+		{cancelled, &r.CancelledAt},
+		{attended, &r.AttendedAt},
+		{noShow, &r.NoShowAt},
+	} {
+		if *f.dst, err = parseNullTime(f.src); err != nil {
+			return services.Reservation{}, err
+		}
+	}
+
+	return r, nil
+}
+
+//
+// ---------- Utilisateurs et sessions ----------
+//
+
+// CreateUser enregistre un nouveau compte (email unique).
+func (s sqlRepo) CreateUser(u services.User) (services.User, error) {
+	_, err := s.q.Exec(
+		`INSERT INTO users (email, password_hash, role, created_at) VALUES (?, ?, ?, ?)`,
+		u.Email, u.PasswordHash, u.Role, formatTime(u.CreatedAt),
+	)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return services.User{}, services.ErrUserExists
+	}
+	if err != nil {
+		return services.User{}, err
+	}
+	return u, nil
+}
+
+// GetUser retourne un compte selon son email.
+func (s sqlRepo) GetUser(email string) (services.User, error) {
+	u, err := scanUser(s.q.QueryRow(
+		`SELECT email, password_hash, role, created_at FROM users WHERE email = ?`,
+		email,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return services.User{}, services.ErrUserNotFound
+	}
+	return u, err
+}
+
+// ListUsers renvoie tous les comptes, par date de création.
+func (s sqlRepo) ListUsers() ([]services.User, error) {
+	rows, err := s.q.Query(`SELECT email, password_hash, role, created_at FROM users ORDER BY created_at, email`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []services.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// UpdateUser remplace un compte existant.
+func (s sqlRepo) UpdateUser(u services.User) (services.User, error) {
+	res, err := s.q.Exec(
+		`UPDATE users SET password_hash = ?, role = ? WHERE email = ?`,
+		u.PasswordHash, u.Role, u.Email,
+	)
+	if err := checkAffected(res, err, services.ErrUserNotFound); err != nil {
+		return services.User{}, err
+	}
+	return u, nil
+}
+
+// scanUser lit une ligne (email, password_hash, role, created_at).
+func scanUser(sc scanner) (services.User, error) {
+	var (
+		u       services.User
+		created string
+	)
+	if err := sc.Scan(&u.Email, &u.PasswordHash, &u.Role, &created); err != nil {
+		return services.User{}, err
+	}
+
+	t, err := parseTime(created)
+	if err != nil {
+		return services.User{}, err
+	}
+	u.CreatedAt = t
+
+	return u, nil
+}
+
+// CreateSession enregistre une session.
+func (s sqlRepo) CreateSession(sess services.Session) (services.Session, error) {
+	_, err := s.q.Exec(
+		`INSERT INTO sessions (id, user_email, created_at, expires_at) VALUES (?, ?, ?, ?)`,
+		sess.ID, sess.UserEmail, formatTime(sess.CreatedAt), formatTime(sess.ExpiresAt),
+	)
+	if err != nil {
+		return services.Session{}, foreignKeyError(err, services.ErrUserNotFound)
+	}
+	return sess, nil
+}
+
+// GetSession retourne une session selon son ID.
+func (s sqlRepo) GetSession(id services.ID) (services.Session, error) {
+	var (
+		sess             services.Session
+		created, expires string
+	)
+	err := s.q.QueryRow(
+		`SELECT id, user_email, created_at, expires_at FROM sessions WHERE id = ?`,
+		id,
+	).Scan(&sess.ID, &sess.UserEmail, &created, &expires)
+	if errors.Is(err, sql.ErrNoRows) {
+		return services.Session{}, services.ErrSessionNotFound
+	}
+	if err != nil {
+		return services.Session{}, err
+	}
+
+	if sess.CreatedAt, err = parseTime(created); err != nil {
+		return services.Session{}, err
+	}
+	if sess.ExpiresAt, err = parseTime(expires); err != nil {
+		return services.Session{}, err
+	}
+	return sess, nil
+}
+
+// DeleteSession supprime une session.
+func (s sqlRepo) DeleteSession(id services.ID) error {
+	res, err := s.q.Exec(`DELETE FROM sessions WHERE id = ?`, id)
+	return checkAffected(res, err, services.ErrSessionNotFound)
+}
+
+// DeleteExpiredSessions supprime les sessions expirées à la date now.
+func (s sqlRepo) DeleteExpiredSessions(now time.Time) (int, error) {
+	res, err := s.q.Exec(`DELETE FROM sessions WHERE expires_at <= ?`, formatTime(now))
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+//
+// ---------- Liens de connexion ----------
+//
+
+// CreateLoginLink enregistre un lien de connexion.
+func (s sqlRepo) CreateLoginLink(l services.LoginLink) (services.LoginLink, error) {
+	_, err := s.q.Exec(
+		`INSERT INTO login_links (id, email, created_at, expires_at) VALUES (?, ?, ?, ?)`,
+		l.ID, l.Email, formatTime(l.CreatedAt), formatTime(l.ExpiresAt),
+	)
+	if err != nil {
+		return services.LoginLink{}, err
+	}
+	return l, nil
+}
+
+// GetLoginLink retourne un lien selon son ID.
+func (s sqlRepo) GetLoginLink(id services.ID) (services.LoginLink, error) {
+	var (
+		l                services.LoginLink
+		created, expires string
+	)
+	err := s.q.QueryRow(
+		`SELECT id, email, created_at, expires_at FROM login_links WHERE id = ?`,
+		id,
+	).Scan(&l.ID, &l.Email, &created, &expires)
+	if errors.Is(err, sql.ErrNoRows) {
+		return services.LoginLink{}, services.ErrLoginLinkNotFound
+	}
+	if err != nil {
+		return services.LoginLink{}, err
+	}
+
+	if l.CreatedAt, err = parseTime(created); err != nil {
+		return services.LoginLink{}, err
+	}
+	if l.ExpiresAt, err = parseTime(expires); err != nil {
+		return services.LoginLink{}, err
+	}
+	return l, nil
+}
+
+// DeleteLoginLink supprime un lien.
+func (s sqlRepo) DeleteLoginLink(id services.ID) error {
+	res, err := s.q.Exec(`DELETE FROM login_links WHERE id = ?`, id)
+	return checkAffected(res, err, services.ErrLoginLinkNotFound)
+}
+
+// DeleteExpiredLoginLinks supprime les liens expirés à la date now.
+func (s sqlRepo) DeleteExpiredLoginLinks(now time.Time) (int, error) {
+	res, err := s.q.Exec(`DELETE FROM login_links WHERE expires_at <= ?`, formatTime(now))
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+//
+// ---------- Rappels ----------
+//
+
+// ListSlotsBetween retourne les créneaux tels que from < datetime <= to, par date.
+func (s sqlRepo) ListSlotsBetween(from, to time.Time) ([]services.Slot, error) {
+	rows, err := s.q.Query(
+		`SELECT `+slotColumns+` FROM slots
+		 WHERE datetime > ? AND datetime <= ? AND deleted_at IS NULL ORDER BY datetime`,
+		formatTime(from), formatTime(to),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []services.Slot
+	for rows.Next() {
+		sl, err := scanSlot(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, sl)
+	}
+	return out, rows.Err()
+}
+
+// HasReminder indique si le rappel a déjà été envoyé pour cette date de
+// créneau (un rappel enregistré sans date, slot_datetime vide, compte pour toutes).
+func (s sqlRepo) HasReminder(resID services.ID, slotTime time.Time, offsetMinutes int) (bool, error) {
+	var n int
+	err := s.q.QueryRow(
+		`SELECT COUNT(*) FROM reminders
+		 WHERE reservation_id = ? AND offset_minutes = ? AND slot_datetime IN ('', ?)`,
+		resID, offsetMinutes, formatTime(slotTime),
+	).Scan(&n)
+	return n > 0, err
+}
+
+// CreateReminder enregistre un rappel envoyé.
+func (s sqlRepo) CreateReminder(r services.Reminder) error {
+	_, err := s.q.Exec(
+		`INSERT INTO reminders (reservation_id, slot_datetime, offset_minutes, sent_at) VALUES (?, ?, ?, ?)`,
+		r.ReservationID, formatTime(r.SlotDatetime), r.OffsetMinutes, formatTime(r.SentAt),
+	)
+	return foreignKeyError(err, services.ErrReservationNotFound)
+}
+
+//
+// ---------- Liste d'attente ----------
+//
+
+// AddWaitlistEntry inscrit un utilisateur sur la liste d'attente d'un créneau.
+func (s sqlRepo) AddWaitlistEntry(w services.WaitlistEntry) (services.WaitlistEntry, error) {
+	if w.ID == "" {
+		w.ID = newID("wl")
+	}
+
+	_, err := s.q.Exec(
+		`INSERT INTO waitlist (id, slot_id, user_email, seats, created_at) VALUES (?, ?, ?, ?, ?)`,
+		w.ID, w.SlotID, w.UserEmail, w.Seats, formatTime(w.CreatedAt),
+	)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return services.WaitlistEntry{}, services.ErrAlreadyWaiting
+	}
+	if err != nil {
+		return services.WaitlistEntry{}, foreignKeyError(err, services.ErrSlotNotFound)
+	}
+	return w, nil
+}
+
+// GetWaitlistEntry retourne une inscription selon son ID.
+func (s sqlRepo) GetWaitlistEntry(id services.ID) (services.WaitlistEntry, error) {
+	w, err := scanWaitlistEntry(s.q.QueryRow(
+		`SELECT id, slot_id, user_email, seats, created_at FROM waitlist WHERE id = ?`,
+		id,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return services.WaitlistEntry{}, services.ErrWaitlistNotFound
+	}
+	return w, err
+}
+
+// ListWaitlistBySlot retourne la file d'un créneau, par ordre d'arrivée.
+func (s sqlRepo) ListWaitlistBySlot(slotID services.ID) ([]services.WaitlistEntry, error) {
+	return s.queryWaitlist(
+		`SELECT id, slot_id, user_email, seats, created_at FROM waitlist WHERE slot_id = ? ORDER BY created_at, id`,
+		slotID,
+	)
+}
+
+// ListWaitlistByEmail retourne les inscriptions d'un utilisateur.
+func (s sqlRepo) ListWaitlistByEmail(email string) ([]services.WaitlistEntry, error) {
+	return s.queryWaitlist(
+		`SELECT id, slot_id, user_email, seats, created_at FROM waitlist WHERE user_email = ? ORDER BY created_at, id`,
+		email,
+	)
+}
+
+// DeleteWaitlistEntry retire une inscription.
+func (s sqlRepo) DeleteWaitlistEntry(id services.ID) error {
+	res, err := s.q.Exec(`DELETE FROM waitlist WHERE id = ?`, id)
+	return checkAffected(res, err, services.ErrWaitlistNotFound)
+}
+
+// queryWaitlist exécute une requête qui renvoie des lignes de la table waitlist.
+func (s sqlRepo) queryWaitlist(query string, args ...any) ([]services.WaitlistEntry, error) {
+	rows, err := s.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []services.WaitlistEntry
+	for rows.Next() {
+		w, err := scanWaitlistEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+// scanWaitlistEntry lit une ligne de la table waitlist.
+func scanWaitlistEntry(sc scanner) (services.WaitlistEntry, error) {
+	var (
+		w       services.WaitlistEntry
+		created string
+	)
+	if err := sc.Scan(&w.ID, &w.SlotID, &w.UserEmail, &w.Seats, &created); err != nil {
+		return services.WaitlistEntry{}, err
+	}
+
+	t, err := parseTime(created)
+	if err != nil {
+		return services.WaitlistEntry{}, err
+	}
+	w.CreatedAt = t
+
+	return w, nil
+}
+
+//
+// ---------- Places retenues ----------
+//
+
+// CreateHold enregistre une place retenue. Le créneau doit exister.
+func (s sqlRepo) CreateHold(h services.Hold) (services.Hold, error) {
+	if h.ID == "" {
+		h.ID = newID("hld")
+	}
+
+	_, err := s.q.Exec(
+		`INSERT INTO holds (id, slot_id, user_email, seats, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		h.ID, h.SlotID, h.UserEmail, h.Seats, formatTime(h.CreatedAt), formatTime(h.ExpiresAt),
+	)
+	if err != nil {
+		return services.Hold{}, foreignKeyError(err, services.ErrSlotNotFound)
+	}
+	return h, nil
+}
+
+// GetHold retourne une place retenue selon son ID.
+func (s sqlRepo) GetHold(id services.ID) (services.Hold, error) {
+	h, err := scanHold(s.q.QueryRow(
+		`SELECT id, slot_id, user_email, seats, created_at, expires_at FROM holds WHERE id = ?`,
+		id,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return services.Hold{}, services.ErrHoldNotFound
+	}
+	return h, err
+}
+
+// ListHoldsBySlot retourne les places retenues d'un créneau, expirées comprises.
+func (s sqlRepo) ListHoldsBySlot(slotID services.ID) ([]services.Hold, error) {
+	return s.queryHolds(
+		`SELECT id, slot_id, user_email, seats, created_at, expires_at FROM holds WHERE slot_id = ? ORDER BY created_at`,
+		slotID,
+	)
+}
+
+// ListHoldsByEmail retourne les places retenues d'un utilisateur, expirées comprises.
+func (s sqlRepo) ListHoldsByEmail(email string) ([]services.Hold, error) {
+	return s.queryHolds(
+		`SELECT id, slot_id, user_email, seats, created_at, expires_at FROM holds WHERE user_email = ? ORDER BY created_at`,
+		email,
+	)
+}
+
+// ListExpiredHolds retourne les places retenues expirées à la date now.
+func (s sqlRepo) ListExpiredHolds(now time.Time) ([]services.Hold, error) {
+	return s.queryHolds(
+		`SELECT id, slot_id, user_email, seats, created_at, expires_at FROM holds WHERE expires_at <= ? ORDER BY expires_at`,
+		formatTime(now),
+	)
+}
+
+// DeleteHold supprime une place retenue.
+func (s sqlRepo) DeleteHold(id services.ID) error {
+	res, err := s.q.Exec(`DELETE FROM holds WHERE id = ?`, id)
+	return checkAffected(res, err, services.ErrHoldNotFound)
+}
+
+// queryHolds exécute une requête qui renvoie des lignes de la table holds.
+func (s sqlRepo) queryHolds(query string, args ...any) ([]services.Hold, error) {
+	rows, err := s.q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []services.Hold
+	for rows.Next() {
+		h, err := scanHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, h)
+	}
+	return out, rows.Err()
+}
+
+// scanHold lit une ligne de la table holds.
+func scanHold(sc scanner) (services.Hold, error) {
+	var (
+		h                services.Hold
+		created, expires string
+	)
+	if err := sc.Scan(&h.ID, &h.SlotID, &h.UserEmail, &h.Seats, &created, &expires); err != nil {
+		return services.Hold{}, err
+	}
+
+	var err error
+	if h.CreatedAt, err = parseTime(created); err != nil {
+		return services.Hold{}, err
+	}
+	if h.ExpiresAt, err = parseTime(expires); err != nil {
+		return services.Hold{}, err
+	}
+	return h, nil
+}
+
+//
+// ---------- Intégrité ----------
+//
+
+// CheckIntegrity recherche les lignes orphelines.
+//
+// Les clés étrangères les empêchent normalement, mais une base modifiée
+// sans PRAGMA foreign_keys (outil externe, import) peut en contenir.
+func (s *SQLStore) CheckIntegrity(repair bool) (services.IntegrityReport, error) {
+	var report services.IntegrityReport
+	err := s.WithTx(func(tx services.Repository) error {
+		var err error
+		report, err = tx.CheckIntegrity(repair)
+		return err
+	})
+	return report, err
+}
+
+// CheckIntegrity recherche les lignes orphelines dans la transaction courante.
+func (t *sqlTx) CheckIntegrity(repair bool) (services.IntegrityReport, error) {
+	report := services.IntegrityReport{
+		OrphanSlots:        []services.Slot{},
+		OrphanReservations: []services.Reservation{},
+	}
+
+	rows, err := t.q.Query(
+		`SELECT ` + slotColumns + ` FROM slots
+		 WHERE service_id NOT IN (SELECT id FROM services)`,
+	)
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		sl, err := scanSlot(rows)
+		if err != nil {
+			return report, err
+		}
+		report.OrphanSlots = append(report.OrphanSlots, sl)
+	}
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	orphans, err := t.queryReservations(
+		`SELECT ` + reservationColumns + ` FROM reservations
+		 WHERE slot_id NOT IN (SELECT s.id FROM slots s JOIN services v ON v.id = s.service_id)`,
+	)
+	if err != nil {
+		return report, err
+	}
+	report.OrphanReservations = append(report.OrphanReservations, orphans...)
+
+	if repair && (len(report.OrphanSlots) > 0 || len(report.OrphanReservations) > 0) {
+		if _, err := t.q.Exec(
+			`DELETE FROM reservations
+			 WHERE slot_id NOT IN (SELECT s.id FROM slots s JOIN services v ON v.id = s.service_id)`,
+		); err != nil {
+			return report, err
+		}
+		for _, table := range []string{"waitlist", "holds"} {
+			if _, err := t.q.Exec(
+				`DELETE FROM ` + table + `
+				 WHERE slot_id NOT IN (SELECT s.id FROM slots s JOIN services v ON v.id = s.service_id)`,
+			); err != nil {
+				return report, err
+			}
+		}
+		if _, err := t.q.Exec(`DELETE FROM slots WHERE service_id NOT IN (SELECT id FROM services)`); err != nil {
+			return report, err
+		}
+		report.Repaired = true
+	}
+
+	return report, nil
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+//
+// ---------- Places occupées et restantes ----------
+//
+
+// SeatUsage = places occupées d'un créneau (voir Repository.CountSeats).
+type SeatUsage struct {
+	Booked int // réservations non annulées
+	Held   int // places retenues non expirées
+}
+
+// setUsage complète info avec les places occupées u du créneau.
+func (info *SlotInfo) setUsage(u SeatUsage) {
+	info.Booked = u.Booked
+	info.Remaining = max(info.Capacity-u.Booked-u.Held, 0)
+	info.Full = info.Remaining == 0
+}
+
+// slotIDs renvoie les identifiants des créneaux.
+func slotIDs(slots []Slot) []ID {
+	ids := make([]ID, 0, len(slots))
+	for _, sl := range slots {
+		ids = append(ids, sl.ID)
+	}
+	return ids
+}
+
+//
+// ---------- Recherche de disponibilités ----------
+//
+
+// Période de recherche par défaut et maximale de SearchAvailability.
+const (
+	DefaultAvailabilityWindow = 30 * 24 * time.Hour
+	MaxAvailabilityWindow     = 92 * 24 * time.Hour
+)
+
+// AvailabilityQuery décrit une recherche de créneaux libres.
+// From et To : RFC3339, heure locale ("2027-01-04T09:00") ou jour
+// ("2027-01-04", To inclus), lus dans le fuseau de l'installation.
+// From vide = maintenant ; To vide = From + DefaultAvailabilityWindow.
+// ServiceID vide = tous les services ; MinSeats 0 = une place.
+type AvailabilityQuery struct {
+	From      string
+	To        string
+	ServiceID ID
+	MinSeats  int
+}
+
+// Availability = créneau à venir où il reste au moins une place
+// (SlotInfo.Remaining), avec le nom de son service.
+type Availability struct {
+	SlotInfo
+	ServiceName string `json:"serviceName"`
+}
+
+// SearchAvailability liste, par date, les créneaux à venir de la période où
+// il reste au moins q.MinSeats places, tous services confondus (ou ceux du
+// service q.ServiceID). Un service dont MaxPartySize est inférieur à
+// MinSeats n'est pas proposé : le groupe ne pourrait pas y réserver.
+func (b *BookingService) SearchAvailability(q AvailabilityQuery) ([]Availability, error) {
+	now := b.now()
+	from, to, err := b.availabilityRange(q, now)
+	if err != nil {
+		return nil, err
+	}
+	if q.MinSeats < 0 {
+		return nil, errors.New("minSeats must be positive")
+	}
+	if q.MinSeats == 0 {
+		q.MinSeats = 1
+	}
+
+	out := []Availability{}
+	err = b.repo.WithTx(func(tx Repository) error {
+		if q.ServiceID != "" {
+			if _, err := tx.GetService(q.ServiceID); err != nil {
+				return err
+			}
+		}
+
+		// Créneaux à venir de la période, From inclus
+		all, err := tx.ListSlotsBetween(from.Add(-time.Nanosecond), to)
+		if err != nil {
+			return err
+		}
+		var slots []Slot
+		for _, sl := range all {
+			if q.ServiceID != "" && sl.ServiceID != q.ServiceID {
+				continue
+			}
+			if sl.Datetime.After(now) {
+				slots = append(slots, sl)
+			}
+		}
+
+		usage, err := tx.CountSeats(slotIDs(slots), now)
+		if err != nil {
+			return err
+		}
+
+		svcs := map[ID]Service{}
+		for _, sl := range slots {
+			svc, ok := svcs[sl.ServiceID]
+			if !ok {
+				if svc, err = tx.GetService(sl.ServiceID); err != nil {
+					return err
+				}
+				svcs[sl.ServiceID] = svc
+			}
+			if svc.MaxPartySize > 0 && q.MinSeats > svc.MaxPartySize {
+				continue
+			}
+
+			info := b.slotInfo(svc, sl)
+			info.setUsage(usage[sl.ID])
+			if info.Remaining < q.MinSeats {
+				continue
+			}
+			out = append(out, Availability{SlotInfo: info, ServiceName: svc.Name})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// availabilityRange lit la période [from, to] d'une recherche.
+func (b *BookingService) availabilityRange(q AvailabilityQuery, now time.Time) (time.Time, time.Time, error) {
+	from := now
+	if q.From != "" {
+		t, err := parseSearchTime(q.From, b.tz, false)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("from: %w", err)
+		}
+		from = t
+	}
+
+	to := from.Add(DefaultAvailabilityWindow)
+	if q.To != "" {
+		t, err := parseSearchTime(q.To, b.tz, true)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("to: %w", err)
+		}
+		to = t
+	}
+
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, errors.New("to is before from")
+	}
+	if to.Sub(from) > MaxAvailabilityWindow {
+		return time.Time{}, time.Time{}, fmt.Errorf("search range is limited to %d days", MaxAvailabilityWindow/(24*time.Hour))
+	}
+	return from, to, nil
+}
+
+// parseSearchTime lit une borne de recherche : date et heure (voir
+// parseSlotTime) ou jour seul, pris à minuit dans le fuseau loc ; endOfDay
+// = jour inclus (la borne devient la fin du jour).
+func parseSearchTime(s string, loc *time.Location, endOfDay bool) (time.Time, error) {
+	if d, err := time.ParseInLocation(time.DateOnly, s, loc); err == nil {
+		if endOfDay {
+			d = d.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		}
+		return d.UTC(), nil
+	}
+	return parseSlotTime(s, loc)
+}
@@ -2,6 +2,8 @@ package services
 
 import (
 	"errors"
+	"fmt"
+	"sort"
 	"time"
 )
 
@@ -18,24 +20,94 @@ type Service struct {
 	Name        string `json:"name"`
 	Description string `json:"description,omitempty"`
 	Duration    int    `json:"duration,omitempty"` // Durée en minutes (facultatif)
+	Owner       string `json:"owner,omitempty"`    // Email du manager propriétaire (facultatif)
+	// MaxPartySize = places maximum par réservation (0 = pas de limite)
+	MaxPartySize int `json:"maxPartySize,omitempty"`
+	// Cancellation = délai d'annulation et déplacements autorisés (voir policy.go)
+	Cancellation CancellationPolicy `json:"cancellation"`
+	// NoOverlap = refuser deux créneaux du service qui se chevauchent (voir schedule.go)
+	NoOverlap bool `json:"noOverlap,omitempty"`
+	// Calendar = horaires d'ouverture et fermetures (voir calendar.go ; nil = toujours ouvert)
+	Calendar *Calendar `json:"calendar,omitempty"`
+	// TimeZone = fuseau IANA du service (voir timezone.go ; "" = fuseau de l'installation)
+	TimeZone string `json:"timeZone,omitempty"`
+	// DeletedAt = date de suppression : le service est archivé (voir Repository.DeleteService)
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
 }
 
-// Slot = créneau horaire disponible pour un service donné
+// Slot = créneau horaire disponible pour un service donné.
+// SeriesID relie les créneaux générés par une même règle de récurrence
+// (voir recurrence.go) ; vide pour un créneau isolé.
+// Resources = personnel, salles ou équipements mobilisés (voir resources.go).
+// Datetime est en UTC ; TimeZone = fuseau du service au moment de la création
+// ou du dernier déplacement (voir timezone.go).
 type Slot struct {
 	ID        ID        `json:"id"`
 	ServiceID ID        `json:"serviceId"`
 	Datetime  time.Time `json:"datetime"`
 	Capacity  int       `json:"capacity"`
+	SeriesID  ID        `json:"seriesId,omitempty"`
+	Resources []ID      `json:"resources,omitempty"`
+	TimeZone  string    `json:"timeZone,omitempty"`
+	// DeletedAt = date de suppression : le créneau est archivé (voir Repository.DeleteSlot)
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
 }
 
-// Reservation = une réservation effectuée par un utilisateur sur un slot
+// Reservation = une réservation effectuée par un utilisateur sur un slot,
+// pour une ou plusieurs places (Seats : réservation de groupe).
+// Status suit son cycle de vie (voir status.go), avec la date de chaque étape.
+// Reschedules compte les déplacements ; LateCancel signale une annulation
+// faite après le délai de prévenance du service.
 type Reservation struct {
-	ID        ID        `json:"id"`
-	SlotID    ID        `json:"slotId"`
-	UserEmail string    `json:"userEmail"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID          ID                `json:"id"`
+	SlotID      ID                `json:"slotId"`
+	UserEmail   string            `json:"userEmail"`
+	Seats       int               `json:"seats"`
+	Status      ReservationStatus `json:"status"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	ConfirmedAt *time.Time        `json:"confirmedAt,omitempty"`
+	CancelledAt *time.Time        `json:"cancelledAt,omitempty"`
+	AttendedAt  *time.Time        `json:"attendedAt,omitempty"`
+	NoShowAt    *time.Time        `json:"noShowAt,omitempty"`
+	Reschedules int               `json:"reschedules,omitempty"`
+	LateCancel  bool              `json:"lateCancel,omitempty"`
+}
+
+// SeatCount renvoie le nombre de places occupées (1 pour une réservation
+// enregistrée avant l'ajout de Seats).
+func (r Reservation) SeatCount() int {
+	return seatCount(r.Seats)
+}
+
+// IntegrityReport liste les données orphelines trouvées dans le stockage.
+//   - OrphanSlots : créneaux dont le service n'existe pas ;
+//   - OrphanReservations : réservations dont le créneau n'existe pas
+//     ou est lui-même orphelin.
+type IntegrityReport struct {
+	OrphanSlots        []Slot        `json:"orphanSlots"`
+	OrphanReservations []Reservation `json:"orphanReservations"`
+	Repaired           bool          `json:"repaired"`
 }
 
+//
+// ---------- Erreurs du domaine ----------
+//
+
+// Erreurs renvoyées par le Repository et le BookingService.
+// Elles permettent à l'appelant de distinguer les cas avec errors.Is.
+var (
+	ErrServiceNotFound     = errors.New("service not found")
+	ErrServiceInUse        = errors.New("service has upcoming reservations")
+	ErrSlotNotFound        = errors.New("slot not found")
+	ErrSlotInUse           = errors.New("slot has upcoming reservations")
+	ErrCapacityTooLow      = errors.New("capacity below current reservations")
+	ErrReservationNotFound = errors.New("reservation not found")
+	ErrNotYourReservation  = errors.New("not your reservation")
+	ErrSlotFull            = errors.New("slot is full")
+	ErrAlreadyBooked       = errors.New("already booked this slot")
+	ErrPartyTooLarge       = errors.New("party size above the service maximum")
+)
+
 //
 // ---------- Interface du Repository (contrat de persistance) ----------
 //
@@ -46,19 +118,94 @@ type Reservation struct {
 type Repository interface {
 	// Services
 	ListServices() ([]Service, error)
+	GetService(serviceID ID) (Service, error)
 	CreateService(s Service) (Service, error)
+	UpdateService(s Service) (Service, error)
+	// DeleteService archive le service et ses slots à la date at : ils
+	// disparaissent des lectures (Get, List), leurs réservations restent
+	// enregistrées pour l'historique ; listes d'attente, places retenues et
+	// ressources des slots sont supprimées.
+	DeleteService(serviceID ID, at time.Time) error
 
 	// Slots
 	AddSlot(slot Slot) (Slot, error)
 	ListSlotsByService(serviceID ID) ([]Slot, error)
+	// ListSlotsByResource retourne les créneaux (tous services) qui mobilisent la ressource.
+	ListSlotsByResource(resourceID ID) ([]Slot, error)
 	GetSlot(slotID ID) (Slot, error)
+	UpdateSlot(slot Slot) (Slot, error)
+	// DeleteSlot archive le slot à la date at, comme DeleteService.
+	DeleteSlot(slotID ID, at time.Time) error
+
+	// Ressources
+	ListResources() ([]Resource, error)
+	GetResource(id ID) (Resource, error)
+	CreateResource(r Resource) (Resource, error)
+	UpdateResource(r Resource) (Resource, error)
+	DeleteResource(id ID) error
 
 	// Réservations
 	CreateReservation(r Reservation) (Reservation, error)
 	ListReservationsByEmail(email string) ([]Reservation, error)
 	ListReservationsBySlot(slotID ID) ([]Reservation, error)
+	// CountSeats compte en une fois les places occupées de chaque créneau de
+	// slotIDs : réservations non annulées et places retenues valables à now.
+	// Un créneau sans place occupée n'apparaît pas dans le résultat.
+	CountSeats(slotIDs []ID, now time.Time) (map[ID]SeatUsage, error)
 	GetReservation(resID ID) (Reservation, error)
+	UpdateReservation(r Reservation) (Reservation, error)
 	DeleteReservation(resID ID) error
+
+	// Utilisateurs et sessions
+	CreateUser(u User) (User, error)
+	GetUser(email string) (User, error)
+	ListUsers() ([]User, error)
+	UpdateUser(u User) (User, error)
+	CreateSession(s Session) (Session, error)
+	GetSession(id ID) (Session, error)
+	DeleteSession(id ID) error
+	DeleteExpiredSessions(now time.Time) (int, error)
+
+	// Liens de connexion (usage unique)
+	CreateLoginLink(l LoginLink) (LoginLink, error)
+	GetLoginLink(id ID) (LoginLink, error)
+	DeleteLoginLink(id ID) error
+	DeleteExpiredLoginLinks(now time.Time) (int, error)
+
+	// Rappels
+	// ListSlotsBetween retourne les créneaux tels que from < datetime <= to, par date.
+	ListSlotsBetween(from, to time.Time) ([]Slot, error)
+	// HasReminder indique si le rappel a déjà été envoyé pour le créneau
+	// de la réservation à la date slotTime (voir Reminder).
+	HasReminder(resID ID, slotTime time.Time, offsetMinutes int) (bool, error)
+	CreateReminder(r Reminder) error
+
+	// Places retenues
+	CreateHold(h Hold) (Hold, error)
+	GetHold(id ID) (Hold, error)
+	// ListHoldsBySlot retourne toutes les places retenues d'un créneau, expirées comprises.
+	ListHoldsBySlot(slotID ID) ([]Hold, error)
+	// ListHoldsByEmail retourne les places retenues d'un utilisateur, expirées comprises.
+	ListHoldsByEmail(email string) ([]Hold, error)
+	ListExpiredHolds(now time.Time) ([]Hold, error)
+	DeleteHold(id ID) error
+
+	// Liste d'attente
+	AddWaitlistEntry(w WaitlistEntry) (WaitlistEntry, error)
+	GetWaitlistEntry(id ID) (WaitlistEntry, error)
+	// ListWaitlistBySlot retourne la file d'un créneau, par ordre d'arrivée.
+	ListWaitlistBySlot(slotID ID) ([]WaitlistEntry, error)
+	ListWaitlistByEmail(email string) ([]WaitlistEntry, error)
+	DeleteWaitlistEntry(id ID) error
+
+	// Intégrité : recherche les slots et réservations orphelins,
+	// et les supprime si repair est vrai.
+	CheckIntegrity(repair bool) (IntegrityReport, error)
+
+	// Transactions : fn reçoit un Repository dont toutes les opérations
+	// s'exécutent de manière atomique (vérification de capacité + création
+	// de la réservation, par exemple). Si fn renvoie une erreur, rien n'est écrit.
+	WithTx(fn func(tx Repository) error) error
 }
 
 //
@@ -68,54 +215,462 @@ type Repository interface {
 // BookingService contient la logique de réservation.
 // Il utilise un Repository pour lire/écrire les données.
 type BookingService struct {
-	repo Repository
-	now  func() time.Time
+	repo     Repository
+	now      func() time.Time
+	notifier Notifier
+	holdTTL  time.Duration
+	tz       *time.Location // fuseau des services sans TimeZone
+	limits   BookingLimits  // limites par utilisateur (voir limits.go)
 }
 
-// NewBookingService instancie un nouveau service métier
-func NewBookingService(r Repository) *BookingService {
-	return &BookingService{
-		repo: r,
-		now:  time.Now, // permet de mocker la date en tests
+// NewBookingService instancie un nouveau service métier.
+// Les options branchent un Notifier ou une autre horloge (voir events.go).
+func NewBookingService(r Repository, opts ...Option) *BookingService {
+	b := &BookingService{
+		repo:    r,
+		now:     time.Now, // permet de mocker la date en tests
+		holdTTL: DefaultHoldTTL,
+		tz:      time.UTC,
 	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
 }
 
 //
 // ---------- Logique Admin ----------
 //
 
-// CreateService permet de créer un service (admin uniquement)
-func (b *BookingService) CreateService(name, desc string, duration int) (Service, error) {
-	if name == "" {
-		return Service{}, errors.New("name required")
+// CreateService permet de créer un service (admin ou manager).
+// L'ID est attribué par le stockage ; svc.Owner = email du manager
+// propriétaire ("" si aucun).
+func (b *BookingService) CreateService(svc Service) (Service, error) {
+	svc.ID = ""
+	svc.Owner = NormalizeEmail(svc.Owner)
+	if svc.Calendar.IsZero() {
+		svc.Calendar = nil
+	}
+	if err := svc.validate(); err != nil {
+		return Service{}, err
+	}
+
+	return b.repo.CreateService(svc)
+}
+
+// validate vérifie les champs d'un service avant enregistrement.
+func (s Service) validate() error {
+	if s.Name == "" {
+		return errors.New("name required")
+	}
+	if s.Duration < 0 {
+		return errors.New("duration must be positive")
+	}
+	if s.MaxPartySize < 0 {
+		return errors.New("max party size must be positive")
+	}
+	if err := s.Calendar.validate(); err != nil {
+		return err
+	}
+	if err := validateTimeZone(s.TimeZone); err != nil {
+		return err
+	}
+	return s.Cancellation.validate()
+}
+
+// ServiceUpdate décrit une modification de service.
+// Un champ nil n'est pas modifié (PATCH) ; PUT renseigne tous les champs.
+type ServiceUpdate struct {
+	Name         *string
+	Description  *string
+	Duration     *int
+	Owner        *string
+	MaxPartySize *int
+	Cancellation *CancellationPolicy
+	NoOverlap    *bool
+	Calendar     *Calendar // calendrier vide = plus aucune contrainte
+	TimeZone     *string   // "" = fuseau de l'installation
+}
+
+// UpdateService applique une modification à un service existant (admin uniquement).
+// Une durée plus longue (ou l'activation de NoOverlap) est refusée si les
+// créneaux à venir du service se chevauchaient alors (ErrSlotOverlap) ou
+// prenaient une ressource déjà occupée (ErrResourceBusy).
+func (b *BookingService) UpdateService(serviceID ID, u ServiceUpdate) (Service, error) {
+	var out Service
+	err := b.repo.WithTx(func(tx Repository) error {
+		svc, err := tx.GetService(serviceID)
+		if err != nil {
+			return err
+		}
+		before := svc
+
+		if u.Name != nil {
+			svc.Name = *u.Name
+		}
+		if u.Description != nil {
+			svc.Description = *u.Description
+		}
+		if u.Duration != nil {
+			svc.Duration = *u.Duration
+		}
+		if u.Owner != nil {
+			svc.Owner = NormalizeEmail(*u.Owner)
+		}
+		if u.MaxPartySize != nil {
+			svc.MaxPartySize = *u.MaxPartySize
+		}
+		if u.Cancellation != nil {
+			svc.Cancellation = *u.Cancellation
+		}
+		if u.NoOverlap != nil {
+			svc.NoOverlap = *u.NoOverlap
+		}
+		if u.Calendar != nil {
+			svc.Calendar = u.Calendar
+			if svc.Calendar.IsZero() {
+				svc.Calendar = nil
+			}
+		}
+		if u.TimeZone != nil {
+			svc.TimeZone = *u.TimeZone
+		}
+		if err := svc.validate(); err != nil {
+			return err
+		}
+		if svc.Length() > before.Length() || (svc.NoOverlap && !before.NoOverlap) {
+			if err := checkServiceSlots(tx, svc, b.now()); err != nil {
+				return err
+			}
+		}
+
+		out, err = tx.UpdateService(svc)
+		return err
+	})
+	if err != nil {
+		return Service{}, err
 	}
 
-	return b.repo.CreateService(Service{
-		Name:        name,
-		Description: desc,
-		Duration:    duration,
+	return out, nil
+}
+
+// DeleteService supprime un service avec tous ses créneaux. Service et
+// créneaux sont archivés : les réservations passées et annulées restent
+// consultables (historique, litiges).
+//
+// Politique de suppression :
+//   - sans réservation à venir, le service est supprimé directement ;
+//   - avec des réservations à venir, la suppression est refusée (ErrServiceInUse)
+//     sauf si cascade est vrai : ces réservations sont alors annulées
+//     et renvoyées à l'appelant pour qu'il puisse prévenir les clients.
+func (b *BookingService) DeleteService(serviceID ID, cascade bool) ([]Reservation, error) {
+	var (
+		cancelled []Reservation
+		events    []Event
+	)
+	err := b.repo.WithTx(func(tx Repository) error {
+		svc, err := tx.GetService(serviceID)
+		if err != nil {
+			return err
+		}
+
+		slots, err := tx.ListSlotsByService(serviceID)
+		if err != nil {
+			return err
+		}
+
+		now := b.now()
+		for _, sl := range slots {
+			if !sl.Datetime.After(now) {
+				continue
+			}
+			res, err := activeReservations(tx, sl.ID)
+			if err != nil {
+				return err
+			}
+			if len(res) == 0 {
+				continue
+			}
+			if !cascade {
+				return ErrServiceInUse
+			}
+
+			if res, err = b.cancelReservations(tx, res); err != nil {
+				return err
+			}
+			cancelled = append(cancelled, res...)
+			events = append(events, newEvents(EventReservationCancelled, svc, sl, res)...)
+		}
+
+		return tx.DeleteService(serviceID, now)
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	b.publish(events)
+	return cancelled, nil
 }
 
 // AddSlot crée un créneau horaire pour un service donné.
-// Le datetime doit être au format RFC3339.
-func (b *BookingService) AddSlot(serviceID ID, isoDatetime string, capacity int) (Slot, error) {
+// Le datetime est au format RFC3339, ou une heure locale sans décalage
+// ("2027-01-04T09:00") lue dans le fuseau du service. Le créneau doit respecter le
+// calendrier du service (ErrOutsideHours, ErrBlackout). Si le service l'exige
+// (NoOverlap), un créneau qui en chevauche un autre est refusé (ErrSlotOverlap) ;
+// une ressource déjà prise au même moment aussi (ErrResourceBusy).
+func (b *BookingService) AddSlot(serviceID ID, isoDatetime string, capacity int, resources []ID) (Slot, error) {
 	if capacity <= 0 {
 		capacity = 1
 	}
 
-	t, err := time.Parse(time.RFC3339, isoDatetime)
+	var out Slot
+	err := b.repo.WithTx(func(tx Repository) error {
+		// Refuser un slot orphelin (ID de service erroné)
+		svc, err := tx.GetService(serviceID)
+		if err != nil {
+			return err
+		}
+		loc := b.location(svc)
+		t, err := parseSlotTime(isoDatetime, loc)
+		if err != nil {
+			return err
+		}
+
+		slot := Slot{
+			ServiceID: serviceID,
+			Datetime:  t,
+			Capacity:  capacity,
+			Resources: normalizeResources(resources),
+			TimeZone:  loc.String(),
+		}
+		if err := checkSchedule(tx, svc, loc, []time.Time{t}, slot.Resources, nil); err != nil {
+			return err
+		}
+
+		out, err = tx.AddSlot(slot)
+		return err
+	})
+	if err != nil {
+		return Slot{}, err
+	}
+
+	return out, nil
+}
+
+// CheckIntegrity renvoie les données orphelines du stockage
+// et les supprime si repair est vrai (admin uniquement).
+func (b *BookingService) CheckIntegrity(repair bool) (IntegrityReport, error) {
+	return b.repo.CheckIntegrity(repair)
+}
+
+// SlotUpdate décrit une modification de créneau (champ nil = inchangé).
+type SlotUpdate struct {
+	Datetime  *string // RFC3339 ou heure locale du service (voir parseSlotTime)
+	Capacity  *int
+	Resources *[]ID
+}
+
+// UpdateSlot déplace un créneau et/ou change sa capacité.
+//
+// Si la nouvelle capacité est inférieure aux places occupées (en nombre de places) :
+//   - bump = false → refus (ErrCapacityTooLow) ;
+//   - bump = true  → les places retenues sont rendues, puis les réservations
+//     les plus récentes en surnombre sont annulées et renvoyées à l'appelant.
+//
+// Avec NoOverlap, le nouvel horaire ne doit chevaucher aucun autre créneau du
+// service ; les ressources doivent être libres au nouvel horaire.
+func (b *BookingService) UpdateSlot(slotID ID, u SlotUpdate, bump bool) (Slot, []Reservation, error) {
+	var (
+		out    Slot
+		bumped []Reservation
+		events []Event
+	)
+	err := b.repo.WithTx(func(tx Repository) error {
+		slot, err := tx.GetSlot(slotID)
+		if err != nil {
+			return err
+		}
+		svc, err := tx.GetService(slot.ServiceID)
+		if err != nil {
+			return err
+		}
+		loc := b.location(svc)
+
+		target, err := u.apply(slot, loc)
+		if err != nil {
+			return err
+		}
+		if !target.Datetime.Equal(slot.Datetime) || !sameResources(target.Resources, slot.Resources) {
+			skip := map[ID]bool{slot.ID: true}
+			if err := checkSchedule(tx, svc, loc, []time.Time{target.Datetime}, target.Resources, skip); err != nil {
+				return err
+			}
+		}
+
+		out, bumped, events, err = b.updateSlot(tx, slot, target, bump)
+		return err
+	})
+	if err != nil {
+		return Slot{}, nil, err
+	}
+
+	b.publish(events)
+	return out, bumped, nil
+}
+
+// apply renvoie le créneau slot modifié par u ; loc = fuseau du service,
+// enregistré avec le créneau s'il est déplacé.
+func (u SlotUpdate) apply(slot Slot, loc *time.Location) (Slot, error) {
+	if u.Datetime != nil {
+		t, err := parseSlotTime(*u.Datetime, loc)
+		if err != nil {
+			return Slot{}, err
+		}
+		if !t.Equal(slot.Datetime) {
+			slot.TimeZone = loc.String()
+		}
+		slot.Datetime = t
+	}
+	if u.Capacity != nil {
+		if *u.Capacity <= 0 {
+			return Slot{}, errors.New("capacity must be positive")
+		}
+		slot.Capacity = *u.Capacity
+	}
+	if u.Resources != nil {
+		slot.Resources = normalizeResources(*u.Resources)
+	}
+	return slot, nil
+}
+
+// updateSlot remplace le créneau before par slot dans la transaction tx
+// (voir UpdateSlot pour bump) et renvoie les réservations annulées et les
+// événements à publier.
+func (b *BookingService) updateSlot(tx Repository, before, slot Slot, bump bool) (Slot, []Reservation, []Event, error) {
+	occ, err := b.slotOccupancy(tx, slot.ID)
+	if err != nil {
+		return Slot{}, nil, nil, err
+	}
+	existing := occ.Reservations
+
+	var bumped []Reservation
+	if over := occ.used() - slot.Capacity; over > 0 {
+		if !bump {
+			return Slot{}, nil, nil, ErrCapacityTooLow
+		}
+
+		// Les places retenues passent avant les réservations
+		for _, h := range occ.Holds {
+			if over <= 0 {
+				break
+			}
+			if err := tx.DeleteHold(h.ID); err != nil {
+				return Slot{}, nil, nil, err
+			}
+			over -= h.SeatCount()
+		}
+
+		// Les premiers arrivés gardent leur place
+		sort.SliceStable(existing, func(i, j int) bool {
+			return existing[i].CreatedAt.After(existing[j].CreatedAt)
+		})
+		n := 0
+		for ; n < len(existing) && over > 0; n++ {
+			r := existing[n]
+			if err := r.setStatus(StatusCancelled, b.now()); err != nil {
+				return Slot{}, nil, nil, err
+			}
+			if _, err := tx.UpdateReservation(r); err != nil {
+				return Slot{}, nil, nil, err
+			}
+			bumped = append(bumped, r)
+			over -= r.SeatCount()
+		}
+		existing = existing[n:]
+	}
+
+	out, err := tx.UpdateSlot(slot)
 	if err != nil {
-		return Slot{}, errors.New("invalid datetime (use RFC3339)")
+		return Slot{}, nil, nil, err
 	}
 
-	slot := Slot{
-		ServiceID: serviceID,
-		Datetime:  t,
-		Capacity:  capacity,
+	// Prévenir les clients annulés, et les autres si l'horaire a changé
+	svc, err := tx.GetService(slot.ServiceID)
+	if err != nil {
+		return Slot{}, nil, nil, err
+	}
+	events := newEvents(EventReservationCancelled, svc, before, bumped)
+	if !out.Datetime.Equal(before.Datetime) {
+		for _, e := range newEvents(EventSlotChanged, svc, out, existing) {
+			e.PreviousDatetime = before.Datetime
+			events = append(events, e)
+		}
 	}
 
-	return b.repo.AddSlot(slot)
+	// Capacité augmentée : des places s'ouvrent pour la liste d'attente
+	promoted, err := b.promoteWaitlist(tx, out, svc)
+	if err != nil {
+		return Slot{}, nil, nil, err
+	}
+	return out, bumped, append(events, promoted...), nil
+}
+
+// DeleteSlot supprime (archive) un créneau.
+//
+// Même politique que DeleteService : refus s'il reste des réservations
+// et que le créneau est à venir, sauf si cascade est vrai (elles sont
+// alors annulées, conservées et renvoyées).
+func (b *BookingService) DeleteSlot(slotID ID, cascade bool) ([]Reservation, error) {
+	var (
+		cancelled []Reservation
+		events    []Event
+	)
+	err := b.repo.WithTx(func(tx Repository) error {
+		slot, err := tx.GetSlot(slotID)
+		if err != nil {
+			return err
+		}
+
+		cancelled, events, err = b.deleteSlot(tx, slot, cascade)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b.publish(events)
+	return cancelled, nil
+}
+
+// deleteSlot supprime slot dans la transaction tx (voir DeleteSlot) et
+// renvoie les réservations annulées et les événements à publier.
+func (b *BookingService) deleteSlot(tx Repository, slot Slot, cascade bool) ([]Reservation, []Event, error) {
+	now := b.now()
+	var cancelled []Reservation
+	if slot.Datetime.After(now) {
+		var err error
+		cancelled, err = activeReservations(tx, slot.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(cancelled) > 0 && !cascade {
+		return nil, nil, ErrSlotInUse
+	}
+
+	svc, err := tx.GetService(slot.ServiceID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if cancelled, err = b.cancelReservations(tx, cancelled); err != nil {
+		return nil, nil, err
+	}
+	if err := tx.DeleteSlot(slot.ID, now); err != nil {
+		return nil, nil, err
+	}
+	return cancelled, newEvents(EventReservationCancelled, svc, slot, cancelled), nil
 }
 
 //
@@ -127,42 +682,264 @@ func (b *BookingService) ListServices() ([]Service, error) {
 	return b.repo.ListServices()
 }
 
-// ListSlotsByService retourne les créneaux d'un service donné
-func (b *BookingService) ListSlotsByService(svcID ID) ([]Slot, error) {
-	return b.repo.ListSlotsByService(svcID)
+// GetService retourne un service selon son ID
+func (b *BookingService) GetService(svcID ID) (Service, error) {
+	return b.repo.GetService(svcID)
+}
+
+// SlotFilter restreint la liste des créneaux d'un service.
+type SlotFilter struct {
+	HideFull bool // sans les créneaux complets
+	HidePast bool // sans les créneaux déjà commencés
+}
+
+// ListSlotsByService retourne les créneaux d'un service donné, par date,
+// avec leur heure de fin et leurs places réservées et restantes
+// (comptées par le Repository en une fois, voir CountSeats).
+func (b *BookingService) ListSlotsByService(svcID ID, f SlotFilter) ([]SlotInfo, error) {
+	svc, err := b.repo.GetService(svcID)
+	if err != nil {
+		return nil, err
+	}
+	slots, err := b.repo.ListSlotsByService(svcID)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(slots, func(i, j int) bool {
+		return slots[i].Datetime.Before(slots[j].Datetime)
+	})
+
+	now := b.now()
+	usage, err := b.repo.CountSeats(slotIDs(slots), now)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]SlotInfo, 0, len(slots))
+	for _, sl := range slots {
+		if f.HidePast && !sl.Datetime.After(now) {
+			continue
+		}
+		info := b.slotInfo(svc, sl)
+		info.setUsage(usage[sl.ID])
+		if f.HideFull && info.Full {
+			continue
+		}
+		out = append(out, info)
+	}
+	return out, nil
 }
 
-// Book tente de réserver un créneau
-func (b *BookingService) Book(slotID ID, userEmail string) (Reservation, error) {
+// Book tente de réserver un créneau.
+//
+// La vérification de capacité et la création de la réservation se font
+// dans une seule transaction : deux réservations simultanées ne peuvent
+// pas dépasser la capacité du créneau. Les limites par utilisateur
+// (BookingLimits) sont vérifiées avant la capacité.
+func (b *BookingService) Book(slotID ID, userEmail string, seats int) (Reservation, error) {
 	if userEmail == "" {
 		return Reservation{}, errors.New("missing user email")
 	}
 
+	var (
+		res   Reservation
+		event Event
+	)
+	err := b.repo.WithTx(func(tx Repository) error {
+		var err error
+		res, event, err = b.book(tx, slotID, userEmail, seats, false)
+		return err
+	})
+	if err != nil {
+		return Reservation{}, err
+	}
+
+	b.publish([]Event{event})
+	return res, nil
+}
+
+// book vérifie la capacité et crée la réservation de seats places dans la
+// transaction tx. Si l'utilisateur était en liste d'attente sur ce créneau,
+// il en sort. held = confirmation d'une place retenue : le délai minimum
+// (checkLeadTime) a été vérifié quand elle a été prise.
+func (b *BookingService) book(tx Repository, slotID ID, userEmail string, seats int, held bool) (Reservation, Event, error) {
 	// Vérifier que le créneau existe
-	slot, err := b.repo.GetSlot(slotID)
+	slot, err := tx.GetSlot(slotID)
+	if err != nil {
+		return Reservation{}, Event{}, ErrSlotNotFound
+	}
+
+	svc, err := tx.GetService(slot.ServiceID)
+	if err != nil {
+		return Reservation{}, Event{}, err
+	}
+
+	seats, err = partySize(svc, seats)
+	if err != nil {
+		return Reservation{}, Event{}, err
+	}
+	if !held {
+		if err := b.checkLeadTime(slot); err != nil {
+			return Reservation{}, Event{}, err
+		}
+	}
+	if err := b.checkLimits(tx, svc, slot, userEmail, ""); err != nil {
+		return Reservation{}, Event{}, err
+	}
+
+	if err := b.claimSeats(tx, slot, userEmail, seats); err != nil {
+		return Reservation{}, Event{}, err
+	}
+
+	// OK → création de la réservation
+	now := b.now()
+	res, err := tx.CreateReservation(Reservation{
+		SlotID:      slotID,
+		UserEmail:   userEmail,
+		Seats:       seats,
+		Status:      StatusConfirmed,
+		CreatedAt:   now,
+		ConfirmedAt: &now,
+	})
 	if err != nil {
-		return Reservation{}, errors.New("slot not found")
+		return Reservation{}, Event{}, err
+	}
+
+	return res, Event{Type: EventReservationCreated, Reservation: res, Slot: slot, Service: svc}, nil
+}
+
+// claimSeats vérifie que userEmail peut occuper seats places sur le créneau,
+// puis libère ce qu'il y détenait déjà : sa place retenue et son inscription
+// en liste d'attente. La réservation elle-même est écrite par l'appelant.
+func (b *BookingService) claimSeats(tx Repository, slot Slot, userEmail string, seats int) error {
+	occ, err := b.slotOccupancy(tx, slot.ID)
+	if err != nil {
+		return err
 	}
 
 	// 1) L'utilisateur ne peut pas réserver deux fois le même slot
-	existing, _ := b.repo.ListReservationsBySlot(slotID)
-	for _, r := range existing {
+	for _, r := range occ.Reservations {
 		if r.UserEmail == userEmail {
-			return Reservation{}, errors.New("already booked this slot")
+			return ErrAlreadyBooked
 		}
 	}
 
-	// 2) Vérifier la capacité maximale
-	if len(existing) >= slot.Capacity {
-		return Reservation{}, errors.New("slot is full")
+	// 2) Vérifier la capacité maximale ; les places que l'utilisateur
+	// a lui-même retenues lui reviennent
+	used := occ.used()
+	own, held := occ.holdOf(userEmail)
+	if held {
+		used -= own.SeatCount()
+	}
+	if used+seats > slot.Capacity {
+		return ErrSlotFull
 	}
 
-	// OK → création de la réservation
-	return b.repo.CreateReservation(Reservation{
-		SlotID:    slotID,
-		UserEmail: userEmail,
-		CreatedAt: b.now(),
+	if held {
+		if err := tx.DeleteHold(own.ID); err != nil {
+			return err
+		}
+	}
+	return leaveWaitlist(tx, slot.ID, userEmail)
+}
+
+// occupancy = places prises sur un créneau : réservations non annulées et
+// places retenues encore valables. Tout contrôle de capacité passe par là.
+type occupancy struct {
+	Reservations []Reservation
+	Holds        []Hold // non expirées, de la plus récente à la plus ancienne
+}
+
+// used renvoie le nombre de places occupées.
+func (o occupancy) used() int {
+	n := 0
+	for _, r := range o.Reservations {
+		n += r.SeatCount()
+	}
+	for _, h := range o.Holds {
+		n += h.SeatCount()
+	}
+	return n
+}
+
+// holdOf renvoie la place retenue par un utilisateur, s'il en a une.
+func (o occupancy) holdOf(userEmail string) (Hold, bool) {
+	for _, h := range o.Holds {
+		if h.UserEmail == userEmail {
+			return h, true
+		}
+	}
+	return Hold{}, false
+}
+
+// slotOccupancy lit l'occupation d'un créneau dans la transaction tx.
+func (b *BookingService) slotOccupancy(tx Repository, slotID ID) (occupancy, error) {
+	var occ occupancy
+
+	res, err := tx.ListReservationsBySlot(slotID)
+	if err != nil {
+		return occupancy{}, err
+	}
+	for _, r := range res {
+		if !r.Cancelled() {
+			occ.Reservations = append(occ.Reservations, r)
+		}
+	}
+
+	holds, err := tx.ListHoldsBySlot(slotID)
+	if err != nil {
+		return occupancy{}, err
+	}
+	now := b.now()
+	for _, h := range holds {
+		if h.ExpiresAt.After(now) {
+			occ.Holds = append(occ.Holds, h)
+		}
+	}
+	sort.SliceStable(occ.Holds, func(i, j int) bool {
+		return occ.Holds[i].CreatedAt.After(occ.Holds[j].CreatedAt)
 	})
+
+	return occ, nil
+}
+
+// activeReservations liste les réservations en attente ou confirmées d'un créneau.
+func activeReservations(tx Repository, slotID ID) ([]Reservation, error) {
+	list, err := tx.ListReservationsBySlot(slotID)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []Reservation
+	for _, r := range list {
+		if r.Active() {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+// partySize valide le nombre de places demandé pour un service
+// (0 = une place) et renvoie la valeur à enregistrer.
+func partySize(svc Service, seats int) (int, error) {
+	if seats == 0 {
+		seats = 1
+	}
+	if seats < 0 {
+		return 0, errors.New("seats must be positive")
+	}
+	if svc.MaxPartySize > 0 && seats > svc.MaxPartySize {
+		return 0, ErrPartyTooLarge
+	}
+	return seats, nil
+}
+
+// seatCount lit un nombre de places enregistré (0 = ancienne donnée = 1 place).
+func seatCount(seats int) int {
+	if seats < 1 {
+		return 1
+	}
+	return seats
 }
 
 // MyReservations retourne les réservations d'un utilisateur
@@ -173,23 +950,206 @@ func (b *BookingService) MyReservations(userEmail string) ([]Reservation, error)
 // Cancel annule une réservation si :
 // - elle existe
 // - elle appartient à l'utilisateur
-// - elle concerne un créneau futur
+// - le délai de prévenance du service n'est pas dépassé (*DeadlineError sinon)
+//
+// La réservation est conservée avec le statut "cancelled". Si le service
+// accepte les annulations tardives, une annulation hors délai (mais avant
+// le créneau) passe et est marquée LateCancel.
 func (b *BookingService) Cancel(resID ID, userEmail string) error {
-	res, err := b.repo.GetReservation(resID)
+	var events []Event
+	err := b.repo.WithTx(func(tx Repository) error {
+		res, err := tx.GetReservation(resID)
+		if err != nil {
+			return ErrReservationNotFound
+		}
+
+		// Vérifier que c’est bien la réservation de cet utilisateur
+		if res.UserEmail != userEmail {
+			return ErrNotYourReservation
+		}
+
+		slot, err := tx.GetSlot(res.SlotID)
+		if err != nil {
+			// Créneau supprimé ou réservation orpheline : annulée
+			// (et conservée) sans notification
+			_, err := b.cancelReservations(tx, []Reservation{res})
+			return err
+		}
+
+		svc, err := tx.GetService(slot.ServiceID)
+		if err != nil {
+			return err
+		}
+
+		// Vérifier le délai d'annulation du service
+		late, err := b.checkChangeWindow(svc, slot, true)
+		if err != nil {
+			return err
+		}
+
+		if err := res.setStatus(StatusCancelled, b.now()); err != nil {
+			return err
+		}
+		res.LateCancel = late
+		if _, err := tx.UpdateReservation(res); err != nil {
+			return err
+		}
+		events = append(events, Event{Type: EventReservationCancelled, Reservation: res, Slot: slot, Service: svc})
+
+		// La place libérée revient au premier de la liste d'attente
+		promoted, err := b.promoteWaitlist(tx, slot, svc)
+		events = append(events, promoted...)
+		return err
+	})
 	if err != nil {
-		return errors.New("reservation not found")
+		return err
 	}
 
-	// Vérifier que c’est bien la réservation de cet utilisateur
-	if res.UserEmail != userEmail {
-		return errors.New("not your reservation")
+	b.publish(events)
+	return nil
+}
+
+// Reschedule déplace une réservation vers un autre créneau du même service,
+// dans une seule transaction : la place sur le nouveau créneau est acquise
+// avant que l'ancienne soit rendue, le client ne peut donc pas perdre les deux.
+//
+// Mêmes délais que Cancel sur l'ancien créneau, sans tolérance pour le retard,
+// et dans la limite de Cancellation.MaxReschedules ; le nouveau créneau doit
+// être à venir et avoir assez de places. La réservation garde son ID et son
+// nombre de places ; la place libérée profite à la liste d'attente de l'ancien créneau.
+func (b *BookingService) Reschedule(resID, newSlotID ID, userEmail string) (Reservation, error) {
+	var (
+		out    Reservation
+		events []Event
+	)
+	err := b.repo.WithTx(func(tx Repository) error {
+		res, err := tx.GetReservation(resID)
+		if err != nil {
+			return ErrReservationNotFound
+		}
+		if res.UserEmail != userEmail {
+			return ErrNotYourReservation
+		}
+		if !res.Active() {
+			return fmt.Errorf("%w: reservation is %s", ErrInvalidTransition, res.Status.OrDefault())
+		}
+		if res.SlotID == newSlotID {
+			return errors.New("reservation is already on this slot")
+		}
+
+		from, err := tx.GetSlot(res.SlotID)
+		if err != nil {
+			return err
+		}
+		svc, err := tx.GetService(from.ServiceID)
+		if err != nil {
+			return err
+		}
+		if _, err := b.checkChangeWindow(svc, from, false); err != nil {
+			return err
+		}
+		if limit := svc.Cancellation.MaxReschedules; limit > 0 && res.Reschedules >= limit {
+			return ErrRescheduleLimit
+		}
+
+		to, err := tx.GetSlot(newSlotID)
+		if err != nil {
+			return ErrSlotNotFound
+		}
+		if to.ServiceID != from.ServiceID {
+			return errors.New("cannot move a reservation to another service")
+		}
+		if !to.Datetime.After(b.now()) {
+			return errors.New("cannot move a reservation to a past slot")
+		}
+		if err := b.checkLeadTime(to); err != nil {
+			return err
+		}
+		if err := b.checkLimits(tx, svc, to, userEmail, res.ID); err != nil {
+			return err
+		}
+
+		if err := b.claimSeats(tx, to, userEmail, res.SeatCount()); err != nil {
+			return err
+		}
+
+		res.SlotID = to.ID
+		res.Reschedules++
+		if out, err = tx.UpdateReservation(res); err != nil {
+			return err
+		}
+
+		events = append(events, Event{
+			Type:             EventReservationRescheduled,
+			Reservation:      out,
+			Slot:             to,
+			Service:          svc,
+			PreviousDatetime: from.Datetime,
+		})
+
+		promoted, err := b.promoteWaitlist(tx, from, svc)
+		events = append(events, promoted...)
+		return err
+	})
+	if err != nil {
+		return Reservation{}, err
 	}
 
-	// Vérifier que le créneau n'est pas passé
-	slot, err := b.repo.GetSlot(res.SlotID)
-	if err == nil && !slot.Datetime.After(b.now()) {
-		return errors.New("cannot cancel past reservations")
+	b.publish(events)
+	return out, nil
+}
+
+// ReduceSeats diminue le nombre de places d'une réservation à venir
+// (un membre du groupe se désiste) sans l'annuler. Les places rendues
+// profitent à la liste d'attente.
+func (b *BookingService) ReduceSeats(resID ID, userEmail string, seats int) (Reservation, error) {
+	var (
+		out    Reservation
+		events []Event
+	)
+	err := b.repo.WithTx(func(tx Repository) error {
+		res, err := tx.GetReservation(resID)
+		if err != nil {
+			return ErrReservationNotFound
+		}
+		if res.UserEmail != userEmail {
+			return ErrNotYourReservation
+		}
+		if !res.Active() {
+			return fmt.Errorf("%w: reservation is %s", ErrInvalidTransition, res.Status.OrDefault())
+		}
+		if seats < 1 {
+			return errors.New("seats must be positive (cancel the reservation instead)")
+		}
+		if seats > res.SeatCount() {
+			return errors.New("seats can only be reduced")
+		}
+
+		slot, err := tx.GetSlot(res.SlotID)
+		if err != nil {
+			return err
+		}
+		svc, err := tx.GetService(slot.ServiceID)
+		if err != nil {
+			return err
+		}
+		// Rendre des places = annulation partielle : mêmes délais que Cancel
+		if _, err := b.checkChangeWindow(svc, slot, true); err != nil {
+			return err
+		}
+
+		res.Seats = seats
+		if out, err = tx.UpdateReservation(res); err != nil {
+			return err
+		}
+
+		events, err = b.promoteWaitlist(tx, slot, svc)
+		return err
+	})
+	if err != nil {
+		return Reservation{}, err
 	}
 
-	return b.repo.DeleteReservation(resID)
-}
\ No newline at end of file
+	b.publish(events)
+	return out, nil
+}
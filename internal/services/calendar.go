@@ -0,0 +1,292 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+//
+// ---------- Horaires d'ouverture et fermetures exceptionnelles ----------
+//
+
+// Calendar = jours et heures où un service peut avoir des créneaux.
+// Sans plage d'ouverture (Hours vide), le service est ouvert à toute heure ;
+// les fermetures (Blackouts, jours fériés) s'appliquent dans tous les cas.
+// Les heures et les dates sont celles du fuseau du service (voir timezone.go).
+type Calendar struct {
+	Hours          []OpeningHours `json:"hours,omitempty"`
+	Blackouts      []Blackout     `json:"blackouts,omitempty"`
+	FrenchHolidays bool           `json:"frenchHolidays,omitempty"` // fermé les jours fériés français
+}
+
+// OpeningHours = plage d'ouverture, les mêmes heures sur plusieurs jours.
+// Exemple : { "days": ["MO","TU"], "open": "09:00", "close": "12:30" }
+type OpeningHours struct {
+	Days  []string `json:"days"`
+	Open  string   `json:"open"`  // HH:MM
+	Close string   `json:"close"` // HH:MM, après Open (même jour)
+}
+
+// Blackout = fermeture exceptionnelle du jour From au jour Until inclus
+// (Until vide = un seul jour).
+type Blackout struct {
+	From   string `json:"from"`            // YYYY-MM-DD
+	Until  string `json:"until,omitempty"` // YYYY-MM-DD
+	Reason string `json:"reason,omitempty"`
+}
+
+// Erreurs liées au calendrier d'un service.
+var (
+	ErrOutsideHours = errors.New("slot is outside the opening hours of this service")
+	ErrBlackout     = errors.New("service is closed on that day")
+)
+
+// IsZero indique un calendrier sans aucune contrainte.
+func (c *Calendar) IsZero() bool {
+	return c == nil || (len(c.Hours) == 0 && len(c.Blackouts) == 0 && !c.FrenchHolidays)
+}
+
+// validate vérifie les jours, heures et dates du calendrier.
+func (c *Calendar) validate() error {
+	if c == nil {
+		return nil
+	}
+	for _, h := range c.Hours {
+		days, err := parseWeekdays(h.Days)
+		if err != nil {
+			return fmt.Errorf("hours: %w", err)
+		}
+		if len(days) == 0 {
+			return errors.New("hours: days required")
+		}
+		open, err := parseClock(h.Open)
+		if err != nil {
+			return fmt.Errorf("hours: %w", err)
+		}
+		closing, err := parseClock(h.Close)
+		if err != nil {
+			return fmt.Errorf("hours: %w", err)
+		}
+		if closing <= open {
+			return fmt.Errorf("hours: close (%s) must be after open (%s)", h.Close, h.Open)
+		}
+	}
+	for _, bo := range c.Blackouts {
+		if _, _, err := bo.dates(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check vérifie qu'un créneau [start, end) tombe dans une plage d'ouverture
+// et pendant aucune fermeture, en heure locale du fuseau loc.
+// L'erreur enveloppe ErrOutsideHours ou ErrBlackout.
+func (c *Calendar) check(start, end time.Time, loc *time.Location) error {
+	if c.IsZero() {
+		return nil
+	}
+	start, end = start.In(loc), end.In(loc)
+
+	// Chaque jour touché par le créneau doit être ouvert
+	last := end
+	if end.After(start) {
+		last = end.Add(-time.Nanosecond)
+	}
+	lastDay := civilDay(last, loc)
+	for d := civilDay(start, loc); !d.After(lastDay); d = d.AddDate(0, 0, 1) {
+		if reason, closed := c.closedOn(d); closed {
+			return fmt.Errorf("%w: %s (%s)", ErrBlackout, d.Format(time.DateOnly), reason)
+		}
+	}
+
+	if len(c.Hours) == 0 {
+		return nil
+	}
+	for _, h := range c.Hours {
+		days, _ := parseWeekdays(h.Days)
+		if !days[start.Weekday()] {
+			continue
+		}
+		open, _ := parseClock(h.Open)
+		closing, _ := parseClock(h.Close)
+		if !start.Before(clockOn(start, open)) && !end.After(clockOn(start, closing)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s – %s", ErrOutsideHours,
+		start.Format(time.RFC3339), end.Format(time.RFC3339))
+}
+
+// closedOn indique si le service est fermé le jour d (voir civilDay), et pourquoi.
+func (c *Calendar) closedOn(d time.Time) (string, bool) {
+	for _, bo := range c.Blackouts {
+		from, until, err := bo.dates()
+		if err != nil || d.Before(from) || d.After(until) {
+			continue
+		}
+		if bo.Reason == "" {
+			return "closed", true
+		}
+		return bo.Reason, true
+	}
+	if c.FrenchHolidays {
+		if name, ok := frenchHolidays(d.Year())[d.Format(time.DateOnly)]; ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// dates renvoie le premier et le dernier jour de la fermeture.
+func (bo Blackout) dates() (time.Time, time.Time, error) {
+	from, err := time.Parse(time.DateOnly, bo.From)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("blackout: invalid from %q (use YYYY-MM-DD)", bo.From)
+	}
+	if bo.Until == "" {
+		return from, from, nil
+	}
+	until, err := time.Parse(time.DateOnly, bo.Until)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("blackout: invalid until %q (use YYYY-MM-DD)", bo.Until)
+	}
+	if until.Before(from) {
+		return time.Time{}, time.Time{}, errors.New("blackout: until is before from")
+	}
+	return from, until, nil
+}
+
+// parseClock lit une heure "HH:MM" et renvoie sa durée depuis minuit.
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q (use HH:MM)", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// clockOn renvoie l'heure d (depuis minuit) du jour de t, dans le fuseau de t.
+// L'heure est comptée sur l'horloge locale : 09:00 reste 09:00 les jours
+// de changement d'heure (heure sautée ou répétée : voir wallTime).
+func clockOn(t time.Time, d time.Duration) time.Time {
+	y, m, day := t.Date()
+	return wallTime(y, m, day, 0, int(d/time.Minute), 0, 0, t.Location())
+}
+
+// frenchHolidays renvoie les jours fériés français de l'année
+// (date YYYY-MM-DD → nom), fêtes mobiles comprises.
+func frenchHolidays(year int) map[string]string {
+	date := func(m time.Month, d int) string {
+		return time.Date(year, m, d, 0, 0, 0, 0, time.UTC).Format(time.DateOnly)
+	}
+	easter := easterSunday(year)
+	after := func(days int) string {
+		return easter.AddDate(0, 0, days).Format(time.DateOnly)
+	}
+
+	return map[string]string{
+		date(time.January, 1):   "Jour de l'an",
+		after(1):                "Lundi de Pâques",
+		date(time.May, 1):       "Fête du Travail",
+		date(time.May, 8):       "Victoire 1945",
+		after(39):               "Ascension",
+		after(50):               "Lundi de Pentecôte",
+		date(time.July, 14):     "Fête nationale",
+		date(time.August, 15):   "Assomption",
+		date(time.November, 1):  "Toussaint",
+		date(time.November, 11): "Armistice 1918",
+		date(time.December, 25): "Noël",
+	}
+}
+
+// easterSunday calcule le dimanche de Pâques (calendrier grégorien,
+// algorithme de Meeus/Jones/Butcher).
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+//
+// ---------- Créneaux en conflit avec le calendrier ----------
+//
+
+// CalendarConflict = créneau à venir qui ne respecte plus le calendrier du
+// service (fermeture ajoutée, horaires réduits) : à déplacer ou supprimer.
+type CalendarConflict struct {
+	Slot         SlotInfo `json:"slot"`
+	Reason       string   `json:"reason"`
+	Reservations int      `json:"reservations"` // réservations actives à prévenir
+}
+
+// CalendarConflicts liste, par date, les créneaux à venir du service qui
+// tombent pendant une fermeture ou hors des horaires d'ouverture.
+func (b *BookingService) CalendarConflicts(serviceID ID) ([]CalendarConflict, error) {
+	out := []CalendarConflict{}
+	err := b.repo.WithTx(func(tx Repository) error {
+		svc, err := tx.GetService(serviceID)
+		if err != nil {
+			return err
+		}
+		if svc.Calendar.IsZero() {
+			return nil
+		}
+
+		slots, err := tx.ListSlotsByService(serviceID)
+		if err != nil {
+			return err
+		}
+		sort.SliceStable(slots, func(i, j int) bool {
+			return slots[i].Datetime.Before(slots[j].Datetime)
+		})
+
+		now := b.now()
+		for _, sl := range slots {
+			if !sl.Datetime.After(now) {
+				continue
+			}
+			cerr := svc.Calendar.check(sl.Datetime, svc.SlotEnd(sl.Datetime), b.location(svc))
+			if cerr == nil {
+				continue
+			}
+			active, err := activeReservations(tx, sl.ID)
+			if err != nil {
+				return err
+			}
+			out = append(out, CalendarConflict{
+				Slot:         b.slotInfo(svc, sl),
+				Reason:       cerr.Error(),
+				Reservations: len(active),
+			})
+		}
+
+		ids := make([]ID, 0, len(out))
+		for _, c := range out {
+			ids = append(ids, c.Slot.ID)
+		}
+		usage, err := tx.CountSeats(ids, now)
+		if err != nil {
+			return err
+		}
+		for i := range out {
+			out[i].Slot.setUsage(usage[out[i].Slot.ID])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// Fêtes mobiles (Pâques + 1, + 39, + 50 jours), dont les années où Pâques
+// tombe au plus tôt (22 mars) et au plus tard (25 avril).
+func TestFrenchMovableHolidays(t *testing.T) {
+	tests := []struct {
+		year                               int
+		easterMonday, ascension, pentecost string
+	}{
+		{2019, "2019-04-22", "2019-05-30", "2019-06-10"},
+		{2024, "2024-04-01", "2024-05-09", "2024-05-20"},
+		{2025, "2025-04-21", "2025-05-29", "2025-06-09"},
+		{2026, "2026-04-06", "2026-05-14", "2026-05-25"},
+		{2027, "2027-03-29", "2027-05-06", "2027-05-17"},
+		{2038, "2038-04-26", "2038-06-03", "2038-06-14"},
+		{2285, "2285-03-23", "2285-04-30", "2285-05-11"},
+	}
+
+	for _, tt := range tests {
+		days := frenchHolidays(tt.year)
+		for date, want := range map[string]string{
+			tt.easterMonday: "Lundi de Pâques",
+			tt.ascension:    "Ascension",
+			tt.pentecost:    "Lundi de Pentecôte",
+		} {
+			if got := days[date]; got != want {
+				t.Errorf("%d: holiday on %s = %q, want %q", tt.year, date, got, want)
+			}
+		}
+		if n := len(days); n != 11 {
+			t.Errorf("%d: %d holidays, want 11", tt.year, n)
+		}
+	}
+}
+
+// Un service qui ferme les jours fériés refuse un créneau le lundi de
+// Pentecôte, mais pas la veille.
+func TestCalendarClosedOnHoliday(t *testing.T) {
+	cal := &Calendar{FrenchHolidays: true}
+	for _, tt := range []struct {
+		day    string
+		closed bool
+	}{
+		{"2026-05-24", false},
+		{"2026-05-25", true},
+		{"2026-05-14", true},
+		{"2026-05-15", false},
+	} {
+		d, err := time.Parse(time.DateOnly, tt.day)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, closed := cal.closedOn(d); closed != tt.closed {
+			t.Errorf("closedOn(%s) = %v, want %v", tt.day, closed, tt.closed)
+		}
+	}
+}
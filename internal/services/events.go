@@ -0,0 +1,103 @@
+package services
+
+import "time"
+
+//
+// ---------- Événements et options du BookingService ----------
+//
+
+// EventType = nature d'un événement de réservation.
+type EventType string
+
+const (
+	EventReservationCreated     EventType = "reservation.created"     // réservation confirmée
+	EventReservationCancelled   EventType = "reservation.cancelled"   // annulée (par le client ou par l'admin)
+	EventSlotChanged            EventType = "slot.changed"            // créneau déplacé
+	EventReminder               EventType = "reservation.reminder"    // rappel avant le créneau
+	EventWaitlistPromoted       EventType = "waitlist.promoted"       // place obtenue depuis la liste d'attente
+	EventReservationRescheduled EventType = "reservation.rescheduled" // déplacée par le client vers un autre créneau
+)
+
+// Event décrit ce qui est arrivé à une réservation, avec le service et le
+// créneau concernés pour que le destinataire n'ait rien à relire.
+// PreviousDatetime n'est renseigné que pour EventSlotChanged et
+// EventReservationRescheduled, ReminderBefore que pour EventReminder.
+type Event struct {
+	Type             EventType
+	Reservation      Reservation
+	Slot             Slot
+	Service          Service
+	PreviousDatetime time.Time
+	ReminderBefore   time.Duration
+}
+
+// Notifier reçoit les événements une fois la transaction validée.
+// Notify ne doit pas bloquer : l'envoi réel se fait en arrière-plan.
+type Notifier interface {
+	Notify(e Event)
+}
+
+// Option configure un BookingService (voir NewBookingService).
+type Option func(*BookingService)
+
+// WithNotifier branche un Notifier (emails de confirmation, annulation...).
+func WithNotifier(n Notifier) Option {
+	return func(b *BookingService) {
+		b.notifier = n
+	}
+}
+
+// WithHoldTTL change la durée de validité des places retenues
+// (DefaultHoldTTL par défaut).
+func WithHoldTTL(ttl time.Duration) Option {
+	return func(b *BookingService) {
+		if ttl > 0 {
+			b.holdTTL = ttl
+		}
+	}
+}
+
+// WithTimeZone change le fuseau des services qui n'en ont pas
+// (UTC par défaut, voir timezone.go).
+func WithTimeZone(loc *time.Location) Option {
+	return func(b *BookingService) {
+		if loc != nil {
+			b.tz = loc
+		}
+	}
+}
+
+// WithBookingLimits active des limites de réservation par utilisateur
+// (voir limits.go ; à vérifier avant avec BookingLimits.Validate).
+func WithBookingLimits(l BookingLimits) Option {
+	return func(b *BookingService) {
+		b.limits = l
+	}
+}
+
+// WithClock remplace l'horloge (tests avec une date fixe).
+func WithClock(now func() time.Time) Option {
+	return func(b *BookingService) {
+		b.now = now
+	}
+}
+
+// newEvents construit un événement par réservation.
+func newEvents(t EventType, svc Service, slot Slot, list []Reservation) []Event {
+	events := make([]Event, 0, len(list))
+	for _, r := range list {
+		events = append(events, Event{Type: t, Reservation: r, Slot: slot, Service: svc})
+	}
+	return events
+}
+
+// publish transmet les événements au Notifier, s'il y en a un.
+// À appeler seulement après le succès de la transaction.
+func (b *BookingService) publish(events []Event) {
+	if b.notifier == nil {
+		return
+	}
+	for _, e := range events {
+		b.notifier.Notify(e)
+	}
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+//
+// ---------- Places retenues pendant la saisie (holds) ----------
+//
+
+// Hold = place retenue sur un créneau le temps que le client termine sa
+// réservation. Elle compte dans la capacité jusqu'à ExpiresAt ; ensuite
+// elle est ignorée, puis supprimée par le balayage (RunHoldSweeper).
+type Hold struct {
+	ID        ID        `json:"id"`
+	SlotID    ID        `json:"slotId"`
+	UserEmail string    `json:"userEmail"`
+	Seats     int       `json:"seats"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// SeatCount renvoie le nombre de places retenues.
+func (h Hold) SeatCount() int {
+	return seatCount(h.Seats)
+}
+
+// DefaultHoldTTL = durée de validité d'une place retenue (voir WithHoldTTL).
+const DefaultHoldTTL = 10 * time.Minute
+
+// Erreurs liées aux places retenues.
+var (
+	ErrHoldNotFound = errors.New("hold not found")
+	ErrHoldExpired  = errors.New("hold expired")
+	ErrAlreadyHeld  = errors.New("already holding a seat on this slot")
+)
+
+// HoldSeats retient seats places sur un créneau à venir pendant b.holdTTL.
+// Si l'utilisateur était en liste d'attente sur ce créneau, il en sort.
+func (b *BookingService) HoldSeats(slotID ID, userEmail string, seats int) (Hold, error) {
+	if userEmail == "" {
+		return Hold{}, errors.New("missing user email")
+	}
+
+	now := b.now()
+	var out Hold
+	err := b.repo.WithTx(func(tx Repository) error {
+		slot, err := tx.GetSlot(slotID)
+		if err != nil {
+			return ErrSlotNotFound
+		}
+		if !slot.Datetime.After(now) {
+			return errors.New("cannot hold a seat on a past slot")
+		}
+
+		svc, err := tx.GetService(slot.ServiceID)
+		if err != nil {
+			return err
+		}
+		if seats, err = partySize(svc, seats); err != nil {
+			return err
+		}
+		if err := b.checkLeadTime(slot); err != nil {
+			return err
+		}
+		if err := b.checkLimits(tx, svc, slot, userEmail, ""); err != nil {
+			return err
+		}
+
+		occ, err := b.slotOccupancy(tx, slotID)
+		if err != nil {
+			return err
+		}
+		for _, r := range occ.Reservations {
+			if r.UserEmail == userEmail {
+				return ErrAlreadyBooked
+			}
+		}
+		for _, h := range occ.Holds {
+			if h.UserEmail == userEmail {
+				return ErrAlreadyHeld
+			}
+		}
+		if occ.used()+seats > slot.Capacity {
+			return ErrSlotFull
+		}
+
+		out, err = tx.CreateHold(Hold{
+			SlotID:    slotID,
+			UserEmail: userEmail,
+			Seats:     seats,
+			CreatedAt: now,
+			ExpiresAt: now.Add(b.holdTTL),
+		})
+		if err != nil {
+			return err
+		}
+		return leaveWaitlist(tx, slotID, userEmail)
+	})
+	if err != nil {
+		return Hold{}, err
+	}
+
+	return out, nil
+}
+
+// ConfirmHold transforme une place retenue (non expirée) en réservation.
+func (b *BookingService) ConfirmHold(id ID, userEmail string) (Reservation, error) {
+	var (
+		res   Reservation
+		event Event
+	)
+	err := b.repo.WithTx(func(tx Repository) error {
+		hold, err := tx.GetHold(id)
+		if err != nil {
+			return err
+		}
+		if hold.UserEmail != userEmail {
+			return ErrHoldNotFound
+		}
+		if !hold.ExpiresAt.After(b.now()) {
+			return ErrHoldExpired
+		}
+
+		// book compte la place retenue comme libre pour son propriétaire,
+		// puis la supprime ; le délai minimum a été vérifié par HoldSeats
+		res, event, err = b.book(tx, hold.SlotID, userEmail, hold.SeatCount(), true)
+		return err
+	})
+	if err != nil {
+		return Reservation{}, err
+	}
+
+	b.publish([]Event{event})
+	return res, nil
+}
+
+// ReleaseHold rend une place retenue avant son expiration.
+// La place libérée profite à la liste d'attente.
+func (b *BookingService) ReleaseHold(id ID, userEmail string) error {
+	var events []Event
+	err := b.repo.WithTx(func(tx Repository) error {
+		hold, err := tx.GetHold(id)
+		if err != nil {
+			return err
+		}
+		if hold.UserEmail != userEmail {
+			return ErrHoldNotFound
+		}
+
+		if err := tx.DeleteHold(id); err != nil {
+			return err
+		}
+
+		events, err = b.promoteAfterRelease(tx, hold.SlotID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	b.publish(events)
+	return nil
+}
+
+// SweepExpiredHolds supprime les places retenues expirées et renvoie
+// leur nombre. Les places rendues profitent à la liste d'attente.
+func (b *BookingService) SweepExpiredHolds() (int, error) {
+	var (
+		n      int
+		events []Event
+	)
+	err := b.repo.WithTx(func(tx Repository) error {
+		expired, err := tx.ListExpiredHolds(b.now())
+		if err != nil {
+			return err
+		}
+
+		slots := map[ID]bool{}
+		for _, h := range expired {
+			if err := tx.DeleteHold(h.ID); err != nil {
+				return err
+			}
+			slots[h.SlotID] = true
+		}
+		n = len(expired)
+
+		for slotID := range slots {
+			promoted, err := b.promoteAfterRelease(tx, slotID)
+			if err != nil {
+				return err
+			}
+			events = append(events, promoted...)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	b.publish(events)
+	return n, nil
+}
+
+// RunHoldSweeper appelle SweepExpiredHolds toutes les interval jusqu'à
+// l'annulation de ctx (lancé en goroutine par cmd/api).
+func (b *BookingService) RunHoldSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := b.SweepExpiredHolds(); err != nil {
+			log.Printf("holds: %v", err)
+		} else if n > 0 {
+			log.Printf("holds: %d expired", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// promoteAfterRelease donne à la liste d'attente les places d'un créneau
+// qui vient de perdre une place retenue.
+func (b *BookingService) promoteAfterRelease(tx Repository, slotID ID) ([]Event, error) {
+	slot, err := tx.GetSlot(slotID)
+	if err != nil {
+		return nil, err
+	}
+	svc, err := tx.GetService(slot.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	return b.promoteWaitlist(tx, slot, svc)
+}
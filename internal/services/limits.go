@@ -0,0 +1,137 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+//
+// ---------- Limites de réservation par utilisateur ----------
+//
+
+// BookingLimits = règles anti-abus vérifiées à chaque nouvelle réservation,
+// place retenue ou déplacement (voir WithBookingLimits). 0 = pas de limite.
+type BookingLimits struct {
+	MaxActive     int           // réservations à venir par utilisateur, tous services confondus
+	MaxPerService int           // réservations à venir par utilisateur sur un même service
+	MaxPerDay     int           // réservations par utilisateur sur un même jour (fuseau du service)
+	MinLeadTime   time.Duration // délai minimum entre la réservation et le début du créneau
+}
+
+// Erreurs des limites de réservation : une par règle, pour que le front
+// puisse expliquer le refus.
+var (
+	ErrTooManyReservations = errors.New("too many upcoming reservations")
+	ErrServiceLimit        = errors.New("too many upcoming reservations for this service")
+	ErrDailyLimit          = errors.New("too many reservations on that day")
+	ErrTooLate             = errors.New("too late to book this slot")
+)
+
+// isLimitError indique si err signale une limite atteinte (et non une
+// erreur du stockage).
+func isLimitError(err error) bool {
+	return errors.Is(err, ErrTooManyReservations) || errors.Is(err, ErrServiceLimit) ||
+		errors.Is(err, ErrDailyLimit) || errors.Is(err, ErrTooLate)
+}
+
+// Validate vérifie que les limites ne sont pas négatives.
+func (l BookingLimits) Validate() error {
+	switch {
+	case l.MaxActive < 0:
+		return errors.New("max active reservations must not be negative")
+	case l.MaxPerService < 0:
+		return errors.New("max reservations per service must not be negative")
+	case l.MaxPerDay < 0:
+		return errors.New("max reservations per day must not be negative")
+	case l.MinLeadTime < 0:
+		return errors.New("minimum lead time must not be negative")
+	}
+	return nil
+}
+
+// IsZero indique qu'aucune limite n'est active.
+func (l BookingLimits) IsZero() bool {
+	return l == BookingLimits{}
+}
+
+// checkLeadTime refuse un créneau qui commence dans moins de MinLeadTime.
+// Le délai se vérifie quand la place est prise (réservation, place retenue,
+// promotion, déplacement) : confirmer une place retenue à temps ne le
+// revérifie pas.
+func (b *BookingService) checkLeadTime(slot Slot) error {
+	l := b.limits.MinLeadTime
+	if l > 0 && slot.Datetime.Sub(b.now()) < l {
+		return fmt.Errorf("%w: bookings close %s before the slot", ErrTooLate, l)
+	}
+	return nil
+}
+
+// checkLimits vérifie que userEmail peut prendre une place sur le créneau
+// slot du service svc sans dépasser MaxActive, MaxPerService ni MaxPerDay.
+// Comptent ses réservations actives et ses places retenues non expirées,
+// sauf celle du créneau slot (elle devient la réservation). skip =
+// réservation ignorée dans les comptes (celle qu'on déplace, "" sinon).
+func (b *BookingService) checkLimits(tx Repository, svc Service, slot Slot, userEmail string, skip ID) error {
+	l := b.limits
+	if l.MaxActive == 0 && l.MaxPerService == 0 && l.MaxPerDay == 0 {
+		return nil
+	}
+
+	now := b.now()
+	loc := b.location(svc)
+	day := civilDay(slot.Datetime, loc)
+	var active, sameService, sameDay int
+	count := func(other Slot) {
+		if civilDay(other.Datetime, loc).Equal(day) {
+			sameDay++
+		}
+		if !other.Datetime.After(now) {
+			return
+		}
+		active++
+		if other.ServiceID == svc.ID {
+			sameService++
+		}
+	}
+
+	list, err := tx.ListReservationsByEmail(userEmail)
+	if err != nil {
+		return err
+	}
+	for _, r := range list {
+		if r.ID == skip || !r.Active() {
+			continue
+		}
+		other, err := tx.GetSlot(r.SlotID)
+		if err != nil {
+			continue // réservation orpheline : voir CheckIntegrity
+		}
+		count(other)
+	}
+
+	holds, err := tx.ListHoldsByEmail(userEmail)
+	if err != nil {
+		return err
+	}
+	for _, h := range holds {
+		if h.SlotID == slot.ID || !h.ExpiresAt.After(now) {
+			continue
+		}
+		other, err := tx.GetSlot(h.SlotID)
+		if err != nil {
+			continue
+		}
+		count(other)
+	}
+
+	switch {
+	case l.MaxActive > 0 && active >= l.MaxActive:
+		return fmt.Errorf("%w (max %d)", ErrTooManyReservations, l.MaxActive)
+	case l.MaxPerService > 0 && sameService >= l.MaxPerService:
+		return fmt.Errorf("%w (max %d)", ErrServiceLimit, l.MaxPerService)
+	case l.MaxPerDay > 0 && sameDay >= l.MaxPerDay:
+		return fmt.Errorf("%w (max %d)", ErrDailyLimit, l.MaxPerDay)
+	}
+	return nil
+}
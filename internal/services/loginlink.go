@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+//
+// ---------- Connexion par lien magique ----------
+//
+
+// LoginLink = lien de connexion à usage unique envoyé par email.
+// Il est supprimé dès qu'il a servi ; après ExpiresAt il n'est plus accepté.
+type LoginLink struct {
+	ID        ID        `json:"id"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Erreurs liées aux liens de connexion.
+var (
+	ErrLoginLinkNotFound = errors.New("login link invalid or already used")
+	ErrLoginLinkExpired  = errors.New("login link expired")
+)
+
+// CreateLoginLink prépare un lien de connexion valable ttl pour email.
+// Les liens expirés sont purgés au passage.
+//
+// Le compte n'a pas besoin d'exister : il sera créé à la première
+// connexion (l'application identifie déjà les clients par leur email).
+func (b *BookingService) CreateLoginLink(email string, ttl time.Duration) (LoginLink, error) {
+	email = NormalizeEmail(email)
+	if email == "" || !strings.Contains(email, "@") {
+		return LoginLink{}, errors.New("valid email required")
+	}
+
+	id, err := newSecretID("lnk")
+	if err != nil {
+		return LoginLink{}, err
+	}
+
+	now := b.now()
+	var out LoginLink
+	err = b.repo.WithTx(func(tx Repository) error {
+		if _, err := tx.DeleteExpiredLoginLinks(now); err != nil {
+			return err
+		}
+
+		out, err = tx.CreateLoginLink(LoginLink{
+			ID:        id,
+			Email:     email,
+			CreatedAt: now,
+			ExpiresAt: now.Add(ttl),
+		})
+		return err
+	})
+	if err != nil {
+		return LoginLink{}, err
+	}
+
+	return out, nil
+}
+
+// ConsumeLoginLink échange un lien contre le compte correspondant.
+//
+// Dans une même transaction : le lien est vérifié puis supprimé (usage
+// unique, même si deux clics arrivent en même temps), et le compte est
+// créé s'il n'existait pas encore (sans mot de passe, rôle client).
+func (b *BookingService) ConsumeLoginLink(id ID) (User, error) {
+	now := b.now()
+
+	var out User
+	err := b.repo.WithTx(func(tx Repository) error {
+		link, err := tx.GetLoginLink(id)
+		if err != nil {
+			return err
+		}
+		if !link.ExpiresAt.After(now) {
+			return ErrLoginLinkExpired
+		}
+
+		if err := tx.DeleteLoginLink(id); err != nil {
+			return err
+		}
+
+		out, err = tx.GetUser(link.Email)
+		if errors.Is(err, ErrUserNotFound) {
+			out, err = tx.CreateUser(User{
+				Email:     link.Email,
+				Role:      RoleCustomer,
+				CreatedAt: now,
+			})
+		}
+		return err
+	})
+	if err != nil {
+		return User{}, err
+	}
+
+	return out, nil
+}
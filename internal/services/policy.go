@@ -0,0 +1,84 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+//
+// ---------- Politique d'annulation par service ----------
+//
+
+// CancellationPolicy = règles d'annulation et de déplacement d'un service.
+// La valeur zéro reproduit l'ancien comportement : annulation et
+// déplacement libres jusqu'au début du créneau.
+type CancellationPolicy struct {
+	NoticeMinutes  int  `json:"noticeMinutes,omitempty"`  // prévenance minimale avant le créneau (0 = jusqu'au début)
+	MaxReschedules int  `json:"maxReschedules,omitempty"` // déplacements par réservation (0 = pas de limite)
+	AllowLate      bool `json:"allowLate,omitempty"`      // annulation hors délai acceptée, mais signalée
+}
+
+// Notice renvoie le délai de prévenance.
+func (p CancellationPolicy) Notice() time.Duration {
+	return time.Duration(p.NoticeMinutes) * time.Minute
+}
+
+// Deadline renvoie le dernier moment où une réservation sur un créneau
+// commençant à start peut être annulée ou déplacée dans les délais.
+func (p CancellationPolicy) Deadline(start time.Time) time.Time {
+	return start.Add(-p.Notice())
+}
+
+// validate refuse les valeurs négatives.
+func (p CancellationPolicy) validate() error {
+	if p.NoticeMinutes < 0 {
+		return errors.New("cancellation notice must be positive")
+	}
+	if p.MaxReschedules < 0 {
+		return errors.New("max reschedules must be positive")
+	}
+	return nil
+}
+
+// Erreurs liées à la politique d'annulation.
+var (
+	ErrCancelDeadline  = errors.New("cancellation deadline passed")
+	ErrRescheduleLimit = errors.New("reschedule limit reached for this reservation")
+)
+
+// DeadlineError = annulation ou modification refusée car le délai de
+// prévenance du service est dépassé. errors.Is(err, ErrCancelDeadline) est vrai.
+type DeadlineError struct {
+	Deadline      time.Time // dernier moment autorisé
+	NoticeMinutes int       // prévenance exigée par le service
+}
+
+func (e *DeadlineError) Error() string {
+	return fmt.Sprintf("%s: changes were allowed until %s (%d minutes before the slot)",
+		ErrCancelDeadline, e.Deadline.UTC().Format(time.RFC3339), e.NoticeMinutes)
+}
+
+func (e *DeadlineError) Unwrap() error {
+	return ErrCancelDeadline
+}
+
+// checkChangeWindow vérifie qu'une réservation sur slot peut encore être
+// annulée ou modifiée par le client selon la politique du service.
+//
+// Hors délai, lateOK autorise l'opération si le service accepte les
+// annulations tardives et que le créneau n'a pas commencé : late vaut alors
+// true. Sinon l'erreur est un *DeadlineError.
+func (b *BookingService) checkChangeWindow(svc Service, slot Slot, lateOK bool) (late bool, err error) {
+	now := b.now()
+	policy := svc.Cancellation
+
+	deadline := policy.Deadline(slot.Datetime)
+	if now.Before(deadline) {
+		return false, nil
+	}
+	if lateOK && policy.AllowLate && slot.Datetime.After(now) {
+		return true, nil
+	}
+	return false, &DeadlineError{Deadline: deadline, NoticeMinutes: policy.NoticeMinutes}
+}
@@ -0,0 +1,503 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// ---------- Créneaux récurrents ----------
+//
+
+// MaxRecurringSlots = nombre maximum de créneaux générés par une série.
+const MaxRecurringSlots = 500
+
+// maxRRuleInterval = INTERVAL maximum d'une règle RRULE.
+const maxRRuleInterval = 365
+
+// ErrTooManyOccurrences = règle qui produirait plus de MaxRecurringSlots créneaux.
+var ErrTooManyOccurrences = fmt.Errorf("recurrence produces more than %d slots", MaxRecurringSlots)
+
+// ErrNotInSeries = opération "ce créneau et les suivants" sur un créneau isolé.
+var ErrNotInSeries = errors.New("slot is not part of a series")
+
+// RecurrenceSpec décrit une série de créneaux, au choix :
+// - RRule : règle iCalendar (sous-ensemble, voir parseRRule) appliquée à partir de Start ;
+// - Weekly : modèle hebdomadaire (jours, heures, période).
+//
+// Capacity et Resources s'appliquent à tous les créneaux générés.
+// Les occurrences sont calculées en heure locale du fuseau du service : un
+// cours à 09:00 reste à 09:00 après un changement d'heure.
+type RecurrenceSpec struct {
+	Start     string // RFC3339 ou heure locale, premier créneau de la règle RRule
+	RRule     string // ex : "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10"
+	Weekly    *WeeklyTemplate
+	Capacity  int
+	Resources []ID
+}
+
+// WeeklyTemplate = créneaux aux mêmes heures, certains jours de la semaine,
+// du jour From au jour Until inclus. Les heures et les jours sont ceux du
+// fuseau du service.
+type WeeklyTemplate struct {
+	Days  []string `json:"days"`  // "MO", "TU", "WE", "TH", "FR", "SA", "SU"
+	Times []string `json:"times"` // "09:00", "14:30"
+	From  string   `json:"from"`  // "2027-01-04"
+	Until string   `json:"until"` // "2027-03-31"
+}
+
+// AddRecurringSlots génère tous les créneaux d'une série en une transaction.
+// Chaque créneau reçoit le même SeriesID, qui permet ensuite de modifier ou
+// supprimer "ce créneau et les suivants" (UpdateSeries, DeleteSeries).
+// Avec NoOverlap, la série est refusée entière si un créneau en chevauche un autre.
+//
+// Les occurrences qui tombent un jour de fermeture du service (jour férié,
+// fermeture exceptionnelle) sont sautées et renvoyées dans skipped ; une
+// occurrence hors des horaires d'ouverture fait refuser la série.
+func (b *BookingService) AddRecurringSlots(serviceID ID, spec RecurrenceSpec) (slots []Slot, skipped []time.Time, err error) {
+	if spec.Capacity <= 0 {
+		spec.Capacity = 1
+	}
+	spec.Resources = normalizeResources(spec.Resources)
+
+	seriesID, err := newSeriesID()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var out []Slot
+	err = b.repo.WithTx(func(tx Repository) error {
+		svc, err := tx.GetService(serviceID)
+		if err != nil {
+			return err
+		}
+		loc := b.location(svc)
+
+		times, err := spec.occurrences(loc)
+		if err != nil {
+			return err
+		}
+		if len(times) == 0 {
+			return errors.New("recurrence produces no slot")
+		}
+
+		kept := times[:0:0]
+		for _, t := range times {
+			if err := svc.Calendar.check(t, svc.SlotEnd(t), loc); errors.Is(err, ErrBlackout) {
+				skipped = append(skipped, t)
+				continue
+			}
+			kept = append(kept, t)
+		}
+		times = kept
+		if len(times) == 0 {
+			return errors.New("recurrence produces no slot outside closing days")
+		}
+
+		if err := checkSchedule(tx, svc, loc, times, spec.Resources, nil); err != nil {
+			return err
+		}
+
+		for _, t := range times {
+			sl, err := tx.AddSlot(Slot{
+				ServiceID: serviceID,
+				Datetime:  t,
+				Capacity:  spec.Capacity,
+				SeriesID:  seriesID,
+				Resources: spec.Resources,
+				TimeZone:  loc.String(),
+			})
+			if err != nil {
+				return err
+			}
+			out = append(out, sl)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return out, skipped, nil
+}
+
+// UpdateSeries applique u à un créneau récurrent et aux suivants de sa série.
+//
+// Un nouvel horaire décale tous ces créneaux du même écart que le premier,
+// en heure locale (le rythme de la série est conservé, changements d'heure
+// compris) ; la capacité et les ressources sont les mêmes pour tous.
+// bump a le même sens que pour UpdateSlot, créneau par créneau.
+func (b *BookingService) UpdateSeries(slotID ID, u SlotUpdate, bump bool) ([]Slot, []Reservation, error) {
+	var (
+		out    []Slot
+		bumped []Reservation
+		events []Event
+	)
+	err := b.repo.WithTx(func(tx Repository) error {
+		first, following, err := seriesFrom(tx, slotID)
+		if err != nil {
+			return err
+		}
+		svc, err := tx.GetService(first.ServiceID)
+		if err != nil {
+			return err
+		}
+		loc := b.location(svc)
+
+		target, err := u.apply(first, loc)
+		if err != nil {
+			return err
+		}
+		moving := !target.Datetime.Equal(first.Datetime)
+		shifted := func(t time.Time) time.Time {
+			return shiftWallClock(t, first.Datetime, target.Datetime, loc)
+		}
+
+		// Contrôle sur les nouveaux horaires de toute la série à la fois
+		if moving || !sameResources(target.Resources, first.Resources) {
+			starts := make([]time.Time, 0, len(following))
+			moved := map[ID]bool{}
+			for _, sl := range following {
+				starts = append(starts, shifted(sl.Datetime))
+				moved[sl.ID] = true
+			}
+			if err := checkSchedule(tx, svc, loc, starts, target.Resources, moved); err != nil {
+				return err
+			}
+		}
+
+		for _, sl := range following {
+			to := sl
+			if moving {
+				to.Datetime = shifted(sl.Datetime)
+				to.TimeZone = loc.String()
+			}
+			to.Capacity = target.Capacity
+			to.Resources = target.Resources
+
+			updated, cancelled, evs, err := b.updateSlot(tx, sl, to, bump)
+			if err != nil {
+				return err
+			}
+			out = append(out, updated)
+			bumped = append(bumped, cancelled...)
+			events = append(events, evs...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b.publish(events)
+	return out, bumped, nil
+}
+
+// DeleteSeries supprime un créneau récurrent et les suivants de sa série,
+// avec la même politique que DeleteSlot (cascade) appliquée à chacun.
+func (b *BookingService) DeleteSeries(slotID ID, cascade bool) ([]Reservation, error) {
+	var (
+		cancelled []Reservation
+		events    []Event
+	)
+	err := b.repo.WithTx(func(tx Repository) error {
+		_, following, err := seriesFrom(tx, slotID)
+		if err != nil {
+			return err
+		}
+
+		for _, sl := range following {
+			res, evs, err := b.deleteSlot(tx, sl, cascade)
+			if err != nil {
+				return err
+			}
+			cancelled = append(cancelled, res...)
+			events = append(events, evs...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	b.publish(events)
+	return cancelled, nil
+}
+
+// seriesFrom renvoie le créneau slotID et les créneaux de sa série qui ne
+// le précèdent pas (lui compris), par date.
+func seriesFrom(tx Repository, slotID ID) (Slot, []Slot, error) {
+	first, err := tx.GetSlot(slotID)
+	if err != nil {
+		return Slot{}, nil, err
+	}
+	if first.SeriesID == "" {
+		return Slot{}, nil, ErrNotInSeries
+	}
+
+	all, err := tx.ListSlotsByService(first.ServiceID)
+	if err != nil {
+		return Slot{}, nil, err
+	}
+
+	var following []Slot
+	for _, sl := range all {
+		if sl.SeriesID == first.SeriesID && !sl.Datetime.Before(first.Datetime) {
+			following = append(following, sl)
+		}
+	}
+	sort.SliceStable(following, func(i, j int) bool {
+		return following[i].Datetime.Before(following[j].Datetime)
+	})
+	return first, following, nil
+}
+
+// newSeriesID génère l'identifiant d'une série. Exemple : "ser_3f9a0c1d2e4b5a69"
+func newSeriesID() (ID, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return ID("ser_" + hex.EncodeToString(b)), nil
+}
+
+//
+// ---------- Calcul des occurrences ----------
+//
+
+// occurrences renvoie les dates (UTC) de tous les créneaux de la série,
+// triées, calculées en heure locale du fuseau loc.
+func (spec RecurrenceSpec) occurrences(loc *time.Location) ([]time.Time, error) {
+	var (
+		times []time.Time
+		err   error
+	)
+	switch {
+	case spec.RRule != "" && spec.Weekly != nil:
+		return nil, errors.New("send either rrule or weekly, not both")
+	case spec.RRule != "":
+		start, perr := parseSlotTime(spec.Start, loc)
+		if perr != nil {
+			return nil, errors.New("invalid start (use RFC3339 or local YYYY-MM-DDTHH:MM)")
+		}
+		rule, perr := parseRRule(spec.RRule, loc)
+		if perr != nil {
+			return nil, perr
+		}
+		times, err = rule.occurrences(start.In(loc))
+	case spec.Weekly != nil:
+		times, err = spec.Weekly.occurrences(loc)
+	default:
+		return nil, errors.New("rrule or weekly required")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i, t := range times {
+		times[i] = t.UTC()
+	}
+	return times, nil
+}
+
+// occurrences déroule le modèle hebdomadaire dans le fuseau loc :
+// une règle par heure de début.
+func (w WeeklyTemplate) occurrences(loc *time.Location) ([]time.Time, error) {
+	days, err := parseWeekdays(w.Days)
+	if err != nil {
+		return nil, err
+	}
+	if len(days) == 0 {
+		return nil, errors.New("weekly: days required")
+	}
+	if len(w.Times) == 0 {
+		return nil, errors.New("weekly: times required")
+	}
+
+	from, err := time.ParseInLocation(time.DateOnly, w.From, loc)
+	if err != nil {
+		return nil, errors.New("weekly: invalid from (use YYYY-MM-DD)")
+	}
+	until, err := time.ParseInLocation(time.DateOnly, w.Until, loc)
+	if err != nil {
+		return nil, errors.New("weekly: invalid until (use YYYY-MM-DD)")
+	}
+	if until.Before(from) {
+		return nil, errors.New("weekly: until is before from")
+	}
+
+	var out []time.Time
+	for _, hhmm := range w.Times {
+		tod, err := time.Parse("15:04", hhmm)
+		if err != nil {
+			return nil, fmt.Errorf("weekly: invalid time %q (use HH:MM)", hhmm)
+		}
+		start := wallTime(from.Year(), from.Month(), from.Day(), tod.Hour(), tod.Minute(), 0, 0, loc)
+
+		rule := rrule{
+			freq:     "WEEKLY",
+			interval: 1,
+			byDay:    days,
+			until:    until.AddDate(0, 0, 1).Add(-time.Second), // jour Until inclus
+		}
+		times, err := rule.occurrences(start)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, times...)
+		if len(out) > MaxRecurringSlots {
+			return nil, ErrTooManyOccurrences
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Before(out[j]) })
+	return out, nil
+}
+
+// rrule = sous-ensemble d'une règle RRULE (RFC 5545).
+type rrule struct {
+	freq     string // DAILY ou WEEKLY
+	interval int
+	byDay    map[time.Weekday]bool // vide = tous les jours (DAILY) ou le jour du début (WEEKLY)
+	count    int                   // 0 = borné par until
+	until    time.Time             // zéro = borné par count
+}
+
+// weekdayCodes = jours au format iCalendar.
+var weekdayCodes = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// parseWeekdays convertit des codes "MO", "TU"... en jours.
+func parseWeekdays(codes []string) (map[time.Weekday]bool, error) {
+	days := make(map[time.Weekday]bool)
+	for _, c := range codes {
+		d, ok := weekdayCodes[strings.ToUpper(strings.TrimSpace(c))]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %q (use MO, TU, WE, TH, FR, SA, SU)", c)
+		}
+		days[d] = true
+	}
+	return days, nil
+}
+
+// parseRRule lit une règle du type "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=10".
+// Reconnu : FREQ (DAILY, WEEKLY), INTERVAL, BYDAY, COUNT, UNTIL
+// (YYYYMMDD, jour du fuseau loc, ou YYYYMMDDTHHMMSSZ). COUNT ou UNTIL est obligatoire.
+func parseRRule(s string, loc *time.Location) (rrule, error) {
+	r := rrule{interval: 1}
+
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	for _, part := range strings.Split(s, ";") {
+		key, val, ok := strings.Cut(part, "=")
+		if !ok {
+			return rrule{}, fmt.Errorf("rrule: invalid part %q", part)
+		}
+
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.freq = strings.ToUpper(val)
+			if r.freq != "DAILY" && r.freq != "WEEKLY" {
+				return rrule{}, fmt.Errorf("rrule: unsupported FREQ %q (use DAILY or WEEKLY)", val)
+			}
+		case "INTERVAL":
+			r.interval, err = strconv.Atoi(val)
+			if err != nil || r.interval < 1 || r.interval > maxRRuleInterval {
+				return rrule{}, fmt.Errorf("rrule: INTERVAL must be between 1 and %d", maxRRuleInterval)
+			}
+		case "BYDAY":
+			if r.byDay, err = parseWeekdays(strings.Split(val, ",")); err != nil {
+				return rrule{}, fmt.Errorf("rrule: %w", err)
+			}
+		case "COUNT":
+			r.count, err = strconv.Atoi(val)
+			if err != nil || r.count < 1 {
+				return rrule{}, errors.New("rrule: COUNT must be a positive integer")
+			}
+		case "UNTIL":
+			if r.until, err = time.Parse("20060102T150405Z", val); err != nil {
+				d, err := time.ParseInLocation("20060102", val, loc)
+				if err != nil {
+					return rrule{}, errors.New("rrule: invalid UNTIL (use YYYYMMDD or YYYYMMDDTHHMMSSZ)")
+				}
+				r.until = d.AddDate(0, 0, 1).Add(-time.Second) // jour UNTIL inclus
+			}
+		default:
+			return rrule{}, fmt.Errorf("rrule: unsupported part %q", key)
+		}
+	}
+
+	if r.freq == "" {
+		return rrule{}, errors.New("rrule: FREQ required")
+	}
+	if r.count == 0 && r.until.IsZero() {
+		return rrule{}, errors.New("rrule: COUNT or UNTIL required")
+	}
+	if r.count > MaxRecurringSlots {
+		return rrule{}, ErrTooManyOccurrences
+	}
+	return r, nil
+}
+
+// occurrences déroule la règle jour par jour à partir de start, à la même
+// heure que start sur l'horloge de son fuseau (changements d'heure compris).
+//
+// Une règle qui produit des créneaux en produit au moins un toutes les
+// 7 × INTERVAL jours : au-delà de MaxRecurringSlots périodes sans créneau,
+// elle n'en produira jamais (ex : FREQ=DAILY;INTERVAL=7;BYDAY=TU commencée
+// un lundi) et est refusée au lieu de boucler indéfiniment.
+func (r rrule) occurrences(start time.Time) ([]time.Time, error) {
+	days := r.byDay
+	if r.freq == "WEEKLY" && len(days) == 0 {
+		days = map[time.Weekday]bool{start.Weekday(): true}
+	}
+
+	// Semaines comptées à partir du lundi de la semaine de start (WKST=MO)
+	offset := (int(start.Weekday()) + 6) % 7
+
+	limit := (MaxRecurringSlots + 1) * 7 * r.interval
+
+	var out []time.Time
+	for i := 0; ; i++ {
+		if i >= limit {
+			if len(out) == 0 {
+				return nil, errors.New("rrule: rule never matches (check INTERVAL and BYDAY)")
+			}
+			return nil, ErrTooManyOccurrences
+		}
+
+		t := wallTime(start.Year(), start.Month(), start.Day()+i,
+			start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+		if !r.until.IsZero() && t.After(r.until) {
+			break
+		}
+
+		match := len(days) == 0 || days[t.Weekday()]
+		switch r.freq {
+		case "DAILY":
+			match = match && i%r.interval == 0
+		case "WEEKLY":
+			match = match && ((offset+i)/7)%r.interval == 0
+		}
+		if !match {
+			continue
+		}
+
+		out = append(out, t)
+		if r.count > 0 && len(out) == r.count {
+			break
+		}
+		if len(out) > MaxRecurringSlots {
+			return nil, ErrTooManyOccurrences
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// Une règle dont BYDAY ne tombe jamais sur un jour généré doit être
+// refusée, pas boucler indéfiniment (la boucle tient la transaction).
+func TestRRuleNeverMatching(t *testing.T) {
+	r, err := parseRRule("FREQ=DAILY;INTERVAL=7;BYDAY=TU;COUNT=3", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	monday := time.Date(2027, 1, 4, 9, 0, 0, 0, time.UTC)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := r.occurrences(monday)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("expected an error for a rule that never matches")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("occurrences did not return")
+	}
+}
+
+func TestRRuleDailyIntervalByDay(t *testing.T) {
+	r, err := parseRRule("FREQ=DAILY;INTERVAL=7;BYDAY=MO;COUNT=3", time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	monday := time.Date(2027, 1, 4, 9, 0, 0, 0, time.UTC)
+
+	got, err := r.occurrences(monday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Time{monday, monday.AddDate(0, 0, 7), monday.AddDate(0, 0, 14)}
+	if len(got) != len(want) {
+		t.Fatalf("got %d occurrences, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("occurrence %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRRuleIntervalTooLarge(t *testing.T) {
+	if _, err := parseRRule("FREQ=DAILY;INTERVAL=1000000000;COUNT=3", time.UTC); err == nil {
+		t.Fatal("expected an error for an INTERVAL above the maximum")
+	}
+}
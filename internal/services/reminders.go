@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+)
+
+//
+// ---------- Rappels avant un créneau ----------
+//
+
+// Reminder mémorise un rappel déjà envoyé, pour ne pas le renvoyer
+// après un redémarrage. OffsetMinutes = délai avant le créneau (1440 = 24h).
+// SlotDatetime = date du créneau annoncée par le rappel : une réservation
+// déplacée (Reschedule) ou dont le créneau change d'horaire reçoit de
+// nouveaux rappels. Vide pour un rappel enregistré avant cet ajout.
+type Reminder struct {
+	ReservationID ID        `json:"reservationId"`
+	SlotDatetime  time.Time `json:"slotDatetime"`
+	OffsetMinutes int       `json:"offsetMinutes"`
+	SentAt        time.Time `json:"sentAt"`
+}
+
+// DefaultReminderOffsets = rappels envoyés 24h puis 1h avant le créneau.
+var DefaultReminderOffsets = []time.Duration{24 * time.Hour, time.Hour}
+
+// SendDueReminders envoie les rappels arrivés à échéance à la date b.now()
+// et renvoie le nombre de rappels publiés.
+//
+// Pour chaque réservation d'un créneau à venir, seul le plus court délai
+// déjà atteint compte : un serveur arrêté pendant la fenêtre des 24h
+// n'envoie pas deux rappels d'un coup au redémarrage. Une réservation
+// prise après l'échéance d'un rappel ne le reçoit pas (l'email de
+// confirmation suffit).
+//
+// Le rappel est enregistré dans la même transaction que la vérification,
+// puis publié : en cas d'arrêt brutal entre les deux, il est perdu plutôt
+// qu'envoyé deux fois.
+func (b *BookingService) SendDueReminders(offsets []time.Duration) (int, error) {
+	if len(offsets) == 0 {
+		return 0, nil
+	}
+
+	// Du plus court au plus long délai
+	offsets = append([]time.Duration(nil), offsets...)
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+	now := b.now()
+	var events []Event
+	err := b.repo.WithTx(func(tx Repository) error {
+		slots, err := tx.ListSlotsBetween(now, now.Add(offsets[len(offsets)-1]))
+		if err != nil {
+			return err
+		}
+
+		for _, slot := range slots {
+			// Plus court délai déjà atteint pour ce créneau
+			var due time.Duration
+			for _, o := range offsets {
+				if !slot.Datetime.After(now.Add(o)) {
+					due = o
+					break
+				}
+			}
+			if due == 0 {
+				continue
+			}
+
+			list, err := tx.ListReservationsBySlot(slot.ID)
+			if err != nil {
+				return err
+			}
+
+			var svc Service
+			for _, r := range list {
+				if !r.Active() || r.CreatedAt.After(slot.Datetime.Add(-due)) {
+					continue
+				}
+
+				sent, err := tx.HasReminder(r.ID, slot.Datetime, int(due.Minutes()))
+				if err != nil {
+					return err
+				}
+				if sent {
+					continue
+				}
+
+				if svc.ID == "" {
+					if svc, err = tx.GetService(slot.ServiceID); err != nil {
+						return err
+					}
+				}
+
+				err = tx.CreateReminder(Reminder{
+					ReservationID: r.ID,
+					SlotDatetime:  slot.Datetime,
+					OffsetMinutes: int(due.Minutes()),
+					SentAt:        now,
+				})
+				if err != nil {
+					return err
+				}
+
+				events = append(events, Event{
+					Type:           EventReminder,
+					Reservation:    r,
+					Slot:           slot,
+					Service:        svc,
+					ReminderBefore: due,
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	b.publish(events)
+	return len(events), nil
+}
+
+// RunReminders appelle SendDueReminders toutes les interval jusqu'à
+// l'annulation de ctx (lancé en goroutine par cmd/api).
+func (b *BookingService) RunReminders(ctx context.Context, interval time.Duration, offsets []time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := b.SendDueReminders(offsets); err != nil {
+			log.Printf("reminders: %v", err)
+		} else if n > 0 {
+			log.Printf("reminders: %d sent", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
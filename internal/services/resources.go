@@ -0,0 +1,185 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+//
+// ---------- Ressources : personnel, salles, équipements ----------
+//
+
+// ResourceKind = nature d'une ressource.
+type ResourceKind string
+
+const (
+	ResourceStaff     ResourceKind = "staff"     // membre de l'équipe
+	ResourceRoom      ResourceKind = "room"      // salle, cabine
+	ResourceEquipment ResourceKind = "equipment" // matériel
+)
+
+// Resource = personne, salle ou équipement mobilisé par un créneau.
+// Une ressource ne peut servir qu'à un créneau à la fois, tous services
+// confondus (voir checkResources).
+type Resource struct {
+	ID   ID           `json:"id"`
+	Name string       `json:"name"`
+	Kind ResourceKind `json:"kind"`
+}
+
+// Erreurs liées aux ressources.
+var (
+	ErrResourceNotFound = errors.New("resource not found")
+	ErrResourceInUse    = errors.New("resource still used by slots")
+	ErrResourceBusy     = errors.New("resource already booked at that time")
+)
+
+// ParseResourceKind valide une nature de ressource reçue de l'extérieur.
+func ParseResourceKind(s string) (ResourceKind, error) {
+	switch k := ResourceKind(s); k {
+	case ResourceStaff, ResourceRoom, ResourceEquipment:
+		return k, nil
+	default:
+		return "", fmt.Errorf("unknown resource kind %q", s)
+	}
+}
+
+// validate vérifie les champs d'une ressource avant enregistrement.
+func (r Resource) validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("name required")
+	}
+	_, err := ParseResourceKind(string(r.Kind))
+	return err
+}
+
+// ListResources retourne toutes les ressources.
+func (b *BookingService) ListResources() ([]Resource, error) {
+	return b.repo.ListResources()
+}
+
+// CreateResource enregistre une nouvelle ressource.
+func (b *BookingService) CreateResource(r Resource) (Resource, error) {
+	r.Name = strings.TrimSpace(r.Name)
+	if err := r.validate(); err != nil {
+		return Resource{}, err
+	}
+	return b.repo.CreateResource(r)
+}
+
+// UpdateResource renomme une ressource ou change sa nature.
+func (b *BookingService) UpdateResource(r Resource) (Resource, error) {
+	r.Name = strings.TrimSpace(r.Name)
+	if err := r.validate(); err != nil {
+		return Resource{}, err
+	}
+	return b.repo.UpdateResource(r)
+}
+
+// DeleteResource supprime une ressource qu'aucun créneau n'utilise plus
+// (sinon ErrResourceInUse : retirer d'abord la ressource des créneaux).
+func (b *BookingService) DeleteResource(id ID) error {
+	return b.repo.WithTx(func(tx Repository) error {
+		if _, err := tx.GetResource(id); err != nil {
+			return err
+		}
+		slots, err := tx.ListSlotsByResource(id)
+		if err != nil {
+			return err
+		}
+		if len(slots) > 0 {
+			return fmt.Errorf("%w (%d slots)", ErrResourceInUse, len(slots))
+		}
+		return tx.DeleteResource(id)
+	})
+}
+
+// normalizeResources trie les identifiants et retire les doublons.
+func normalizeResources(ids []ID) []ID {
+	if len(ids) == 0 {
+		return nil
+	}
+	out := append([]ID(nil), ids...)
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+
+	n := 0
+	for i, id := range out {
+		if i > 0 && id == out[n-1] {
+			continue
+		}
+		out[n] = id
+		n++
+	}
+	return out[:n]
+}
+
+// sameResources indique si deux listes normalisées sont identiques.
+func sameResources(a, b []ID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// checkResources vérifie que les ressources existent et qu'aucune n'est déjà
+// prise, par un créneau de n'importe quel service, pendant l'un des créneaux
+// du service svc commençant à starts. skip = créneaux existants ignorés
+// (ceux qu'on déplace).
+func checkResources(tx Repository, svc Service, starts []time.Time, resources []ID, skip map[ID]bool) error {
+	if len(resources) == 0 || len(starts) == 0 {
+		return nil
+	}
+
+	// Les créneaux prévus ne peuvent pas se chevaucher entre eux :
+	// ils mobilisent les mêmes ressources
+	planned := append([]time.Time(nil), starts...)
+	sort.Slice(planned, func(i, j int) bool { return planned[i].Before(planned[j]) })
+	for i := 1; i < len(planned); i++ {
+		if overlaps(planned[i-1], svc.SlotEnd(planned[i-1]), planned[i], svc.SlotEnd(planned[i])) {
+			return fmt.Errorf("%w: %s at %s", ErrResourceBusy, resources[0], planned[i].UTC().Format(time.RFC3339))
+		}
+	}
+
+	// Durée des autres services, lue une seule fois
+	lengths := map[ID]time.Duration{svc.ID: svc.Length()}
+	for _, resID := range resources {
+		if _, err := tx.GetResource(resID); err != nil {
+			return err
+		}
+
+		taken, err := tx.ListSlotsByResource(resID)
+		if err != nil {
+			return err
+		}
+		for _, sl := range taken {
+			if skip[sl.ID] {
+				continue
+			}
+			length, ok := lengths[sl.ServiceID]
+			if !ok {
+				other, err := tx.GetService(sl.ServiceID)
+				if err != nil {
+					return err
+				}
+				length = other.Length()
+				lengths[sl.ServiceID] = length
+			}
+
+			for _, t := range planned {
+				if overlaps(t, svc.SlotEnd(t), sl.Datetime, sl.Datetime.Add(length)) {
+					return fmt.Errorf("%w: %s is used by slot %s at %s",
+						ErrResourceBusy, resID, sl.ID, sl.Datetime.UTC().Format(time.RFC3339))
+				}
+			}
+		}
+	}
+	return nil
+}
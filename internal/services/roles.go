@@ -0,0 +1,147 @@
+package services
+
+import "errors"
+
+//
+// ---------- Rôles et permissions ----------
+//
+
+// Role = niveau d'accès d'un utilisateur.
+type Role string
+
+const (
+	RoleCustomer Role = "customer" // client : réserve et annule ses créneaux
+	RoleStaff    Role = "staff"    // accueil : gère les créneaux de tous les services
+	RoleManager  Role = "manager"  // responsable : gère ses propres services et leurs créneaux
+	RoleAdmin    Role = "admin"    // administrateur : tous les droits
+)
+
+// Permission = action protégée, vérifiée route par route.
+type Permission string
+
+const (
+	PermBook            Permission = "book"             // réserver / annuler pour soi
+	PermManageSlots     Permission = "slots:manage"     // créer, modifier, supprimer des créneaux
+	PermManageServices  Permission = "services:manage"  // créer, modifier, supprimer des services
+	PermManageResources Permission = "resources:manage" // modifier, supprimer des ressources partagées
+	PermIntegrity       Permission = "integrity"        // rapport / réparation d'intégrité
+	PermManageUsers     Permission = "users:manage"     // lister les comptes, changer les rôles
+)
+
+// rolePermissions associe chaque rôle à ses permissions.
+var rolePermissions = map[Role][]Permission{
+	RoleCustomer: {PermBook},
+	RoleStaff:    {PermBook, PermManageSlots},
+	RoleManager:  {PermBook, PermManageSlots, PermManageServices},
+	RoleAdmin:    {PermBook, PermManageSlots, PermManageServices, PermManageResources, PermIntegrity, PermManageUsers},
+}
+
+// ErrForbidden = l'utilisateur n'a pas le droit d'effectuer l'action.
+var ErrForbidden = errors.New("forbidden")
+
+// ParseRole valide un nom de rôle.
+func ParseRole(s string) (Role, error) {
+	r := Role(s)
+	if _, ok := rolePermissions[r]; !ok {
+		return "", errors.New("unknown role (customer, staff, manager, admin)")
+	}
+	return r, nil
+}
+
+// OrDefault traite un rôle vide (comptes créés avant les rôles) comme client.
+func (r Role) OrDefault() Role {
+	if r == "" {
+		return RoleCustomer
+	}
+	return r
+}
+
+// Can indique si le rôle possède la permission.
+func (r Role) Can(p Permission) bool {
+	for _, perm := range rolePermissions[r.OrDefault()] {
+		if perm == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions retourne la liste des permissions du rôle.
+func (r Role) Permissions() []Permission {
+	return append([]Permission(nil), rolePermissions[r.OrDefault()]...)
+}
+
+// ownedOnly indique si les permissions du rôle sont limitées
+// aux services dont l'utilisateur est propriétaire.
+func (r Role) ownedOnly() bool {
+	return r.OrDefault() == RoleManager
+}
+
+// CanManageService vérifie que u peut exercer perm sur le service serviceID :
+// permission du rôle, puis propriété du service pour un manager.
+func (b *BookingService) CanManageService(u User, perm Permission, serviceID ID) error {
+	if !u.Role.Can(perm) {
+		return ErrForbidden
+	}
+	if !u.Role.ownedOnly() {
+		return nil
+	}
+
+	svc, err := b.repo.GetService(serviceID)
+	if err != nil {
+		return err
+	}
+	if svc.Owner != u.Email {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// CanManageSlot vérifie que u peut exercer perm sur le service du créneau.
+func (b *BookingService) CanManageSlot(u User, perm Permission, slotID ID) error {
+	slot, err := b.repo.GetSlot(slotID)
+	if err != nil {
+		return err
+	}
+	return b.CanManageService(u, perm, slot.ServiceID)
+}
+
+// CanManageReservation vérifie que u peut exercer perm sur le service du
+// créneau réservé.
+func (b *BookingService) CanManageReservation(u User, perm Permission, resID ID) error {
+	res, err := b.repo.GetReservation(resID)
+	if err != nil {
+		return err
+	}
+	return b.CanManageSlot(u, perm, res.SlotID)
+}
+
+//
+// ---------- Gestion des comptes (admin) ----------
+//
+
+// ListUsers retourne tous les comptes.
+func (b *BookingService) ListUsers() ([]User, error) {
+	return b.repo.ListUsers()
+}
+
+// SetRole change le rôle d'un compte.
+func (b *BookingService) SetRole(email string, role Role) (User, error) {
+	var out User
+	err := b.repo.WithTx(func(tx Repository) error {
+		u, err := tx.GetUser(NormalizeEmail(email))
+		if err != nil {
+			return err
+		}
+
+		u.Role = role
+		out, err = tx.UpdateUser(u)
+		return err
+	})
+	if err != nil {
+		return User{}, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+//
+// ---------- Horaires des créneaux : fin et chevauchements ----------
+//
+
+// Length renvoie la durée d'un créneau du service (0 si Duration n'est pas renseignée).
+func (s Service) Length() time.Duration {
+	return time.Duration(s.Duration) * time.Minute
+}
+
+// SlotEnd renvoie la fin d'un créneau du service commençant à start.
+func (s Service) SlotEnd(start time.Time) time.Time {
+	return start.Add(s.Length())
+}
+
+// SlotInfo = créneau avec son début et sa fin (calculée à partir de la durée
+// du service), tel que l'affiche le calendrier public : en UTC et en heure
+// locale du fuseau du créneau (TimeZone), avec ses places occupées et
+// restantes (voir setUsage).
+type SlotInfo struct {
+	Slot
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	LocalStart time.Time `json:"localStart"` // ex : "2027-01-04T10:00:00+01:00"
+	LocalEnd   time.Time `json:"localEnd"`
+	Booked     int       `json:"booked"`    // places réservées (réservations non annulées)
+	Remaining  int       `json:"remaining"` // places libres (places retenues déduites)
+	Full       bool      `json:"full"`
+}
+
+// slotInfo complète un créneau du service svc avec son début et sa fin.
+func (b *BookingService) slotInfo(svc Service, sl Slot) SlotInfo {
+	loc := b.slotLocation(svc, sl)
+	sl.TimeZone = loc.String()
+	start, end := sl.Datetime.UTC(), svc.SlotEnd(sl.Datetime).UTC()
+	sl.Datetime = start
+	return SlotInfo{
+		Slot:       sl,
+		Start:      start,
+		End:        end,
+		LocalStart: start.In(loc),
+		LocalEnd:   end.In(loc),
+	}
+}
+
+// ErrSlotOverlap = créneau refusé car il chevauche un autre créneau du
+// service (services avec NoOverlap).
+var ErrSlotOverlap = errors.New("slot overlaps another slot of this service")
+
+// checkSchedule refuse les créneaux du service svc (fuseau loc) commençant à
+// starts qui tomberaient hors de son calendrier (voir calendar.go), chevaucheraient un
+// autre créneau du service (NoOverlap) ou prendraient une ressource déjà
+// occupée (voir checkResources).
+func checkSchedule(tx Repository, svc Service, loc *time.Location, starts []time.Time, resources []ID, skip map[ID]bool) error {
+	for _, t := range starts {
+		if err := svc.Calendar.check(t, svc.SlotEnd(t), loc); err != nil {
+			return err
+		}
+	}
+	if err := checkOverlap(tx, svc, starts, skip); err != nil {
+		return err
+	}
+	return checkResources(tx, svc, starts, resources, skip)
+}
+
+// checkServiceSlots revérifie les créneaux non terminés du service svc après
+// un allongement de sa durée ou l'activation de NoOverlap : ils ne doivent
+// ni se chevaucher (ErrSlotOverlap) ni prendre une ressource déjà occupée
+// pendant leur nouvelle durée (ErrResourceBusy).
+func checkServiceSlots(tx Repository, svc Service, now time.Time) error {
+	slots, err := tx.ListSlotsByService(svc.ID)
+	if err != nil {
+		return err
+	}
+
+	var starts []time.Time
+	current := map[ID]bool{}
+	for _, sl := range slots {
+		if svc.SlotEnd(sl.Datetime).After(now) {
+			starts = append(starts, sl.Datetime)
+			current[sl.ID] = true
+		}
+	}
+	if err := checkOverlap(tx, svc, starts, current); err != nil {
+		return err
+	}
+
+	for _, sl := range slots {
+		if !current[sl.ID] {
+			continue
+		}
+		if err := checkResources(tx, svc, []time.Time{sl.Datetime}, sl.Resources, map[ID]bool{sl.ID: true}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkOverlap vérifie, si le service l'exige (NoOverlap), que les créneaux
+// commençant à starts ne se chevauchent ni entre eux ni avec les créneaux
+// existants du service. skip = créneaux existants ignorés (ceux qu'on déplace).
+func checkOverlap(tx Repository, svc Service, starts []time.Time, skip map[ID]bool) error {
+	if !svc.NoOverlap || len(starts) == 0 {
+		return nil
+	}
+
+	existing, err := tx.ListSlotsByService(svc.ID)
+	if err != nil {
+		return err
+	}
+
+	planned := append([]time.Time(nil), starts...)
+	sort.Slice(planned, func(i, j int) bool { return planned[i].Before(planned[j]) })
+	for i := 1; i < len(planned); i++ {
+		if overlaps(planned[i-1], svc.SlotEnd(planned[i-1]), planned[i], svc.SlotEnd(planned[i])) {
+			return fmt.Errorf("%w at %s", ErrSlotOverlap, planned[i].UTC().Format(time.RFC3339))
+		}
+	}
+
+	for _, t := range planned {
+		for _, sl := range existing {
+			if skip[sl.ID] {
+				continue
+			}
+			if overlaps(t, svc.SlotEnd(t), sl.Datetime, svc.SlotEnd(sl.Datetime)) {
+				return fmt.Errorf("%w: %s at %s", ErrSlotOverlap, sl.ID, sl.Datetime.UTC().Format(time.RFC3339))
+			}
+		}
+	}
+	return nil
+}
+
+// overlaps indique si les créneaux [aStart, aEnd) et [bStart, bEnd) se
+// chevauchent. Deux créneaux qui commencent en même temps se chevauchent
+// toujours, même sans durée.
+func overlaps(aStart, aEnd, bStart, bEnd time.Time) bool {
+	if aStart.Equal(bStart) {
+		return true
+	}
+	return aStart.Before(bEnd) && bStart.Before(aEnd)
+}
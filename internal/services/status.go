@@ -0,0 +1,184 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+//
+// ---------- Cycle de vie d'une réservation ----------
+//
+
+// ReservationStatus = étape du cycle de vie d'une réservation.
+// Une réservation est confirmée dès sa création (la saisie en cours est
+// couverte par les places retenues, voir holds.go). Une réservation
+// annulée reste enregistrée (historique, litiges) mais ne compte plus
+// dans la capacité du créneau.
+type ReservationStatus string
+
+const (
+	StatusConfirmed ReservationStatus = "confirmed" // place garantie
+	StatusCancelled ReservationStatus = "cancelled" // annulée (client ou équipe)
+	StatusAttended  ReservationStatus = "attended"  // client venu
+	StatusNoShow    ReservationStatus = "no_show"   // client absent
+)
+
+// ErrInvalidTransition = changement de statut interdit (ex : annuler une
+// réservation déjà annulée, pointer la présence avant le créneau).
+var ErrInvalidTransition = errors.New("status change not allowed")
+
+// statusTransitions[statut actuel] = statuts accessibles.
+// Présent ↔ absent reste modifiable pour corriger une erreur de pointage.
+var statusTransitions = map[ReservationStatus][]ReservationStatus{
+	StatusConfirmed: {StatusCancelled, StatusAttended, StatusNoShow},
+	StatusAttended:  {StatusNoShow},
+	StatusNoShow:    {StatusAttended},
+}
+
+// ParseReservationStatus valide un statut reçu de l'extérieur.
+func ParseReservationStatus(s string) (ReservationStatus, error) {
+	switch st := ReservationStatus(s); st {
+	case StatusConfirmed, StatusCancelled, StatusAttended, StatusNoShow:
+		return st, nil
+	default:
+		return "", fmt.Errorf("unknown status %q", s)
+	}
+}
+
+// OrDefault traite le statut vide (réservation enregistrée avant
+// l'ajout des statuts) comme confirmé.
+func (s ReservationStatus) OrDefault() ReservationStatus {
+	if s == "" {
+		return StatusConfirmed
+	}
+	return s
+}
+
+// Active indique si la réservation est encore à venir pour le client
+// (confirmée) : rappels, annulation, modification.
+func (r Reservation) Active() bool {
+	return r.Status.OrDefault() == StatusConfirmed
+}
+
+// Cancelled indique si la réservation a été annulée ; elle ne compte
+// alors plus dans la capacité du créneau.
+func (r Reservation) Cancelled() bool {
+	return r.Status.OrDefault() == StatusCancelled
+}
+
+// setStatus applique une transition et horodate le nouveau statut.
+func (r *Reservation) setStatus(to ReservationStatus, at time.Time) error {
+	from := r.Status.OrDefault()
+	allowed := false
+	for _, st := range statusTransitions[from] {
+		if st == to {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("%w: %s → %s", ErrInvalidTransition, from, to)
+	}
+
+	r.Status = to
+	switch to {
+	case StatusCancelled:
+		r.CancelledAt = &at
+	case StatusAttended:
+		r.AttendedAt = &at
+	case StatusNoShow:
+		r.NoShowAt = &at
+	}
+	return nil
+}
+
+// cancelReservations annule les réservations list dans la transaction tx
+// (suppression de leur créneau ou de leur service) et les renvoie à jour :
+// elles restent enregistrées pour l'historique.
+func (b *BookingService) cancelReservations(tx Repository, list []Reservation) ([]Reservation, error) {
+	now := b.now()
+	out := make([]Reservation, 0, len(list))
+	for _, r := range list {
+		if err := r.setStatus(StatusCancelled, now); err != nil {
+			return nil, err
+		}
+		r, err := tx.UpdateReservation(r)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// SlotReservations liste toutes les réservations d'un créneau, annulées
+// comprises (pointage des présences par l'équipe).
+func (b *BookingService) SlotReservations(slotID ID) ([]Reservation, error) {
+	if _, err := b.repo.GetSlot(slotID); err != nil {
+		return nil, err
+	}
+	return b.repo.ListReservationsBySlot(slotID)
+}
+
+// SetReservationStatus fait avancer une réservation dans son cycle de vie
+// (équipe : annulation, présence, absence).
+//
+// La présence et l'absence ne se pointent qu'une fois le créneau commencé ;
+// l'annulation seulement avant. Une annulation prévient
+// le client et libère la place pour la liste d'attente.
+func (b *BookingService) SetReservationStatus(resID ID, status ReservationStatus) (Reservation, error) {
+	var (
+		out    Reservation
+		events []Event
+	)
+	err := b.repo.WithTx(func(tx Repository) error {
+		res, err := tx.GetReservation(resID)
+		if err != nil {
+			return err
+		}
+		slot, err := tx.GetSlot(res.SlotID)
+		if err != nil {
+			return err
+		}
+
+		now := b.now()
+		started := !slot.Datetime.After(now)
+		switch status {
+		case StatusAttended, StatusNoShow:
+			if !started {
+				return fmt.Errorf("%w: slot has not started yet", ErrInvalidTransition)
+			}
+		default:
+			if started {
+				return fmt.Errorf("%w: slot already started", ErrInvalidTransition)
+			}
+		}
+
+		if err := res.setStatus(status, now); err != nil {
+			return err
+		}
+		if out, err = tx.UpdateReservation(res); err != nil {
+			return err
+		}
+
+		if status == StatusCancelled {
+			svc, err := tx.GetService(slot.ServiceID)
+			if err != nil {
+				return err
+			}
+			events = append(events, Event{Type: EventReservationCancelled, Reservation: out, Slot: slot, Service: svc})
+
+			promoted, err := b.promoteWaitlist(tx, slot, svc)
+			events = append(events, promoted...)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return Reservation{}, err
+	}
+
+	b.publish(events)
+	return out, nil
+}
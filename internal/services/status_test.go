@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSetStatusTransitions(t *testing.T) {
+	at := time.Date(2027, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		from, to ReservationStatus
+		ok       bool
+	}{
+		{"", StatusCancelled, true}, // réservation enregistrée avant les statuts
+		{StatusConfirmed, StatusCancelled, true},
+		{StatusConfirmed, StatusAttended, true},
+		{StatusConfirmed, StatusNoShow, true},
+		{StatusConfirmed, StatusConfirmed, false},
+		{StatusCancelled, StatusCancelled, false},
+		{StatusCancelled, StatusConfirmed, false},
+		{StatusCancelled, StatusAttended, false},
+		{StatusAttended, StatusNoShow, true},
+		{StatusAttended, StatusCancelled, false},
+		{StatusNoShow, StatusAttended, true},
+		{StatusNoShow, StatusConfirmed, false},
+	}
+	for _, tt := range tests {
+		r := Reservation{Status: tt.from}
+		err := r.setStatus(tt.to, at)
+		if tt.ok {
+			if err != nil {
+				t.Errorf("%q → %q: unexpected error: %v", tt.from, tt.to, err)
+				continue
+			}
+			if r.Status != tt.to {
+				t.Errorf("%q → %q: status = %q", tt.from, tt.to, r.Status)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("%q → %q: got %v, want ErrInvalidTransition", tt.from, tt.to, err)
+		}
+		if r.Status != tt.from {
+			t.Errorf("%q → %q: status changed to %q on a refused transition", tt.from, tt.to, r.Status)
+		}
+	}
+}
+
+func TestSetStatusTimestamps(t *testing.T) {
+	at := time.Date(2027, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	r := Reservation{Status: StatusConfirmed}
+	if err := r.setStatus(StatusNoShow, at); err != nil {
+		t.Fatal(err)
+	}
+	if r.NoShowAt == nil || !r.NoShowAt.Equal(at) {
+		t.Fatalf("NoShowAt = %v, want %s", r.NoShowAt, at)
+	}
+	if err := r.setStatus(StatusAttended, at.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if r.AttendedAt == nil || !r.AttendedAt.Equal(at.Add(time.Minute)) {
+		t.Fatalf("AttendedAt = %v, want %s", r.AttendedAt, at.Add(time.Minute))
+	}
+
+	c := Reservation{Status: StatusConfirmed}
+	if err := c.setStatus(StatusCancelled, at); err != nil {
+		t.Fatal(err)
+	}
+	if c.CancelledAt == nil || !c.CancelledAt.Equal(at) {
+		t.Fatalf("CancelledAt = %v, want %s", c.CancelledAt, at)
+	}
+}
+
+func TestParseReservationStatus(t *testing.T) {
+	for _, s := range []string{"confirmed", "cancelled", "attended", "no_show"} {
+		if _, err := ParseReservationStatus(s); err != nil {
+			t.Errorf("ParseReservationStatus(%q): %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "pending", "CONFIRMED"} {
+		if _, err := ParseReservationStatus(s); err == nil {
+			t.Errorf("ParseReservationStatus(%q): expected an error", s)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+//
+// ---------- Fuseaux horaires ----------
+//
+
+// Les dates sont enregistrées en UTC. Chaque service a un fuseau IANA
+// (Service.TimeZone, ex : "Europe/Paris"), à défaut celui de l'installation
+// (WithTimeZone, UTC sinon) : c'est dans ce fuseau que sont lues les heures
+// sans décalage, les modèles hebdomadaires, les horaires d'ouverture et les
+// fermetures. Chaque créneau garde le fuseau dans lequel il a été créé ou
+// déplacé (Slot.TimeZone), pour l'affichage en heure locale.
+
+// localLayouts = formats acceptés pour une heure locale, sans décalage.
+var localLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+// validateTimeZone vérifie un nom de fuseau IANA ("" = fuseau par défaut).
+func validateTimeZone(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("unknown time zone %q (use an IANA name such as Europe/Paris)", name)
+	}
+	return nil
+}
+
+// location renvoie le fuseau du service svc.
+func (b *BookingService) location(svc Service) *time.Location {
+	if svc.TimeZone != "" {
+		if loc, err := time.LoadLocation(svc.TimeZone); err == nil {
+			return loc
+		}
+	}
+	return b.tz
+}
+
+// slotLocation renvoie le fuseau d'affichage d'un créneau du service svc :
+// celui enregistré avec le créneau, sinon celui du service.
+func (b *BookingService) slotLocation(svc Service, sl Slot) *time.Location {
+	if sl.TimeZone != "" {
+		if loc, err := time.LoadLocation(sl.TimeZone); err == nil {
+			return loc
+		}
+	}
+	return b.location(svc)
+}
+
+// parseSlotTime lit la date d'un créneau : RFC3339 (avec décalage), ou heure
+// locale du fuseau loc ("2027-01-04T09:00"). Le résultat est en UTC.
+func parseSlotTime(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UTC(), nil
+	}
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, errors.New("invalid datetime (use RFC3339 or local YYYY-MM-DDTHH:MM)")
+}
+
+// civilDay renvoie le jour de t dans le fuseau loc, à minuit UTC
+// (pour comparer des dates sans se soucier des changements d'heure).
+func civilDay(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// shiftWallClock décale t, en heure locale du fuseau loc, du même écart
+// qu'entre from et to : même nombre de jours et même écart d'horloge.
+// Un créneau de 10:00 déplacé à 11:00 reste à 11:00 après un changement d'heure.
+func shiftWallClock(t, from, to time.Time, loc *time.Location) time.Time {
+	f, g := from.In(loc), to.In(loc)
+	days := int(civilDay(g, loc).Sub(civilDay(f, loc)) / (24 * time.Hour))
+	minutes := (g.Hour()-f.Hour())*60 + g.Minute() - f.Minute()
+	seconds := g.Second() - f.Second()
+
+	l := t.In(loc)
+	return wallTime(l.Year(), l.Month(), l.Day()+days,
+		l.Hour(), l.Minute()+minutes, l.Second()+seconds, l.Nanosecond(), loc).UTC()
+}
+
+// wallTime est time.Date, avec une règle fixe pour les heures locales qui
+// n'existent pas ou existent deux fois (comme la RFC 5545) :
+//   - heure sautée au passage à l'heure d'été (02:30 fin mars à Paris) :
+//     décalage d'avant le saut, donc 03:30 heure d'été ;
+//   - heure répétée au passage à l'heure d'hiver (02:30 fin octobre) :
+//     la première des deux, encore en heure d'été.
+//
+// time.Date ne garantit pas lequel des deux instants il choisit.
+func wallTime(year int, month time.Month, day, hour, min, sec, nsec int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, min, sec, nsec, loc)
+
+	// Heure locale voulue, normalisée (jour 32, minute 90...)
+	want := time.Date(year, month, day, hour, min, sec, nsec, time.UTC)
+
+	// Heure répétée : autre instant, plus tôt, avec le même affichage ?
+	for _, probe := range []time.Time{want.Add(-24 * time.Hour), want.Add(24 * time.Hour)} {
+		_, offset := time.Date(probe.Year(), probe.Month(), probe.Day(), probe.Hour(), 0, 0, 0, loc).Zone()
+		other := want.Add(-time.Duration(offset) * time.Second)
+		if other.Before(t) && sameWallClock(other.In(loc), want) {
+			t = other
+		}
+	}
+	return t
+}
+
+// sameWallClock compare la date et l'heure affichées de a et b, sans le fuseau.
+func sameWallClock(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd &&
+		a.Hour() == b.Hour() && a.Minute() == b.Minute() && a.Second() == b.Second() &&
+		a.Nanosecond() == b.Nanosecond()
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// paris charge Europe/Paris : en 2027, passage à l'heure d'été le 28 mars
+// (02:00 → 03:00) et à l'heure d'hiver le 31 octobre (03:00 → 02:00).
+func paris(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Skipf("Europe/Paris unavailable: %v", err)
+	}
+	return loc
+}
+
+func utc(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestClockOnDST(t *testing.T) {
+	loc := paris(t)
+	tests := []struct {
+		name  string
+		day   time.Time
+		clock string
+		want  string
+	}{
+		{"ordinary day", time.Date(2027, 6, 1, 12, 0, 0, 0, loc), "09:00", "2027-06-01T07:00:00Z"},
+		{"spring forward, 09:00", time.Date(2027, 3, 28, 12, 0, 0, 0, loc), "09:00", "2027-03-28T07:00:00Z"},
+		{"spring forward, skipped 02:30", time.Date(2027, 3, 28, 12, 0, 0, 0, loc), "02:30", "2027-03-28T01:30:00Z"},
+		{"fall back, 09:00", time.Date(2027, 10, 31, 12, 0, 0, 0, loc), "09:00", "2027-10-31T08:00:00Z"},
+		{"fall back, repeated 02:30", time.Date(2027, 10, 31, 12, 0, 0, 0, loc), "02:30", "2027-10-31T00:30:00Z"},
+		{"fall back, 03:30", time.Date(2027, 10, 31, 12, 0, 0, 0, loc), "03:30", "2027-10-31T02:30:00Z"},
+	}
+
+	for _, tt := range tests {
+		d, err := parseClock(tt.clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := clockOn(tt.day, d).UTC(); !got.Equal(utc(tt.want)) {
+			t.Errorf("%s: clockOn(%s) = %s, want %s", tt.name, tt.clock, got.Format(time.RFC3339), tt.want)
+		}
+	}
+}
+
+// Déplacer un créneau d'un jour garde son heure locale, y compris quand
+// elle tombe dans l'heure sautée ou répétée.
+func TestShiftWallClockDST(t *testing.T) {
+	loc := paris(t)
+	nextDay := func(s string) (time.Time, time.Time) {
+		from := utc(s)
+		return from, from.In(loc).AddDate(0, 0, 1)
+	}
+	tests := []struct {
+		name string
+		slot string
+		want string
+	}{
+		{"10:00 across spring forward", "2027-03-27T09:00:00Z", "2027-03-28T08:00:00Z"},
+		{"02:30 into the skipped hour", "2027-03-27T01:30:00Z", "2027-03-28T01:30:00Z"},
+		{"10:00 across fall back", "2027-10-30T08:00:00Z", "2027-10-31T09:00:00Z"},
+		{"02:30 into the repeated hour", "2027-10-30T00:30:00Z", "2027-10-31T00:30:00Z"},
+	}
+
+	for _, tt := range tests {
+		from, to := nextDay(tt.slot)
+		if got := shiftWallClock(from, from, to, loc); !got.Equal(utc(tt.want)) {
+			t.Errorf("%s: got %s, want %s", tt.name, got.Format(time.RFC3339), tt.want)
+		}
+	}
+}
+
+// Une série quotidienne à 02:30 garde la première occurrence le jour du
+// passage à l'heure d'hiver, et ne crée pas de doublon.
+func TestRecurrenceRepeatedHour(t *testing.T) {
+	loc := paris(t)
+	r, err := parseRRule("FREQ=DAILY;COUNT=3", loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.occurrences(time.Date(2027, 10, 30, 2, 30, 0, 0, loc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"2027-10-30T00:30:00Z", "2027-10-31T00:30:00Z", "2027-11-01T01:30:00Z"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d occurrences, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if !got[i].Equal(utc(want[i])) {
+			t.Errorf("occurrence %d = %s, want %s", i, got[i].UTC().Format(time.RFC3339), want[i])
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+//
+// ---------- Comptes utilisateurs et sessions ----------
+//
+
+// User = compte d'un client, d'un membre du personnel ou d'un administrateur.
+// Le mot de passe n'est jamais stocké en clair : seul son hash bcrypt l'est.
+type User struct {
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"passwordHash,omitempty"`
+	Role         Role      `json:"role,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// Session = connexion ouverte par un utilisateur, valable jusqu'à ExpiresAt.
+type Session struct {
+	ID        ID        `json:"id"`
+	UserEmail string    `json:"userEmail"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Erreurs liées aux comptes et aux sessions.
+var (
+	ErrUserExists         = errors.New("user already exists")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrSessionExpired     = errors.New("session expired")
+)
+
+// minPasswordLength = longueur minimale d'un mot de passe.
+const minPasswordLength = 8
+
+// NormalizeEmail met un email sous la forme utilisée comme identifiant
+// (sans espaces, en minuscules).
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// newSecretID génère un identifiant aléatoire (256 bits) pour les sessions
+// et liens de connexion, impossible à deviner contrairement aux IDs basés
+// sur l'horloge. Exemple : "ses_9f86d0..."
+func newSecretID(prefix string) (ID, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return ID(prefix + "_" + hex.EncodeToString(b)), nil
+}
+
+// Register crée un compte avec un mot de passe hashé en bcrypt.
+func (b *BookingService) Register(email, password string) (User, error) {
+	email = NormalizeEmail(email)
+	if email == "" || !strings.Contains(email, "@") {
+		return User{}, errors.New("valid email required")
+	}
+	if len(password) < minPasswordLength {
+		return User{}, errors.New("password must be at least 8 characters")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return User{}, err
+	}
+
+	return b.repo.CreateUser(User{
+		Email:        email,
+		PasswordHash: string(hash),
+		Role:         RoleCustomer,
+		CreatedAt:    b.now(),
+	})
+}
+
+// Authenticate vérifie un couple email / mot de passe.
+//
+// Le message d'erreur est le même que l'email soit inconnu ou le mot de passe
+// faux, pour ne pas révéler quels comptes existent.
+func (b *BookingService) Authenticate(email, password string) (User, error) {
+	u, err := b.repo.GetUser(NormalizeEmail(email))
+	if err != nil || u.PasswordHash == "" {
+		return User{}, ErrInvalidCredentials
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) != nil {
+		return User{}, ErrInvalidCredentials
+	}
+
+	return u, nil
+}
+
+// OpenSession crée une session de durée ttl pour l'utilisateur.
+// Les sessions expirées sont purgées au passage.
+func (b *BookingService) OpenSession(email string, ttl time.Duration) (Session, error) {
+	id, err := newSecretID("ses")
+	if err != nil {
+		return Session{}, err
+	}
+
+	now := b.now()
+	var out Session
+	err = b.repo.WithTx(func(tx Repository) error {
+		if _, err := tx.DeleteExpiredSessions(now); err != nil {
+			return err
+		}
+
+		out, err = tx.CreateSession(Session{
+			ID:        id,
+			UserEmail: NormalizeEmail(email),
+			CreatedAt: now,
+			ExpiresAt: now.Add(ttl),
+		})
+		return err
+	})
+	if err != nil {
+		return Session{}, err
+	}
+
+	return out, nil
+}
+
+// ResolveSession retourne l'utilisateur d'une session encore valide.
+func (b *BookingService) ResolveSession(id ID) (User, error) {
+	sess, err := b.repo.GetSession(id)
+	if err != nil {
+		return User{}, err
+	}
+	if !sess.ExpiresAt.After(b.now()) {
+		return User{}, ErrSessionExpired
+	}
+
+	return b.repo.GetUser(sess.UserEmail)
+}
+
+// CloseSession supprime une session (déconnexion).
+func (b *BookingService) CloseSession(id ID) error {
+	return b.repo.DeleteSession(id)
+}
+
+// EnsureUser crée le compte s'il n'existe pas encore et lui donne le rôle
+// role (utilisé au démarrage pour l'administrateur). Le mot de passe d'un
+// compte existant n'est pas modifié.
+func (b *BookingService) EnsureUser(email, password string, role Role) error {
+	u, err := b.repo.GetUser(NormalizeEmail(email))
+	if errors.Is(err, ErrUserNotFound) {
+		u, err = b.Register(email, password)
+	}
+	if err != nil {
+		return err
+	}
+
+	if u.Role == role {
+		return nil
+	}
+	_, err = b.SetRole(u.Email, role)
+	return err
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"errors"
+	"time"
+)
+
+//
+// ---------- Liste d'attente des créneaux complets ----------
+//
+
+// WaitlistEntry = inscription d'un client sur la liste d'attente d'un créneau.
+// L'ordre d'arrivée (CreatedAt) décide qui obtient la prochaine place libre.
+type WaitlistEntry struct {
+	ID        ID        `json:"id"`
+	SlotID    ID        `json:"slotId"`
+	UserEmail string    `json:"userEmail"`
+	Seats     int       `json:"seats"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// SeatCount renvoie le nombre de places attendues.
+func (w WaitlistEntry) SeatCount() int {
+	return seatCount(w.Seats)
+}
+
+// WaitlistPosition = inscription + rang dans la file (1 = prochain servi).
+type WaitlistPosition struct {
+	WaitlistEntry
+	Position int `json:"position"`
+}
+
+// Erreurs liées à la liste d'attente.
+var (
+	ErrWaitlistNotFound = errors.New("waitlist entry not found")
+	ErrAlreadyWaiting   = errors.New("already on the waitlist for this slot")
+)
+
+// BookOrWait réserve le créneau s'il reste de la place, sinon inscrit
+// l'utilisateur sur la liste d'attente (dans la même transaction : pas de
+// place libérée entre la vérification et l'inscription).
+//
+// Les limites par utilisateur (BookingLimits) s'appliquent aussi à
+// l'inscription : book les vérifie avant la capacité, un utilisateur qui a
+// atteint une limite n'arrive donc jamais jusqu'à la liste d'attente.
+//
+// Une seule des deux valeurs est renseignée : la réservation (ID non vide)
+// ou la position dans la file.
+func (b *BookingService) BookOrWait(slotID ID, userEmail string, seats int) (Reservation, WaitlistPosition, error) {
+	if userEmail == "" {
+		return Reservation{}, WaitlistPosition{}, errors.New("missing user email")
+	}
+
+	var (
+		res    Reservation
+		pos    WaitlistPosition
+		events []Event
+	)
+	err := b.repo.WithTx(func(tx Repository) error {
+		r, event, err := b.book(tx, slotID, userEmail, seats, false)
+		if err == nil {
+			res = r
+			events = append(events, event)
+			return nil
+		}
+		if !errors.Is(err, ErrSlotFull) {
+			return err
+		}
+
+		// Créneau complet → liste d'attente, s'il n'est pas déjà passé
+		slot, err := tx.GetSlot(slotID)
+		if err != nil {
+			return err
+		}
+		if !slot.Datetime.After(b.now()) {
+			return errors.New("cannot join the waitlist of a past slot")
+		}
+		if seats > slot.Capacity {
+			// Le groupe ne tiendrait jamais dans le créneau
+			return ErrSlotFull
+		}
+
+		queue, err := tx.ListWaitlistBySlot(slotID)
+		if err != nil {
+			return err
+		}
+		for _, w := range queue {
+			if w.UserEmail == userEmail {
+				return ErrAlreadyWaiting
+			}
+		}
+
+		entry, err := tx.AddWaitlistEntry(WaitlistEntry{
+			SlotID:    slotID,
+			UserEmail: userEmail,
+			Seats:     seatCount(seats),
+			CreatedAt: b.now(),
+		})
+		if err != nil {
+			return err
+		}
+		pos = WaitlistPosition{WaitlistEntry: entry, Position: len(queue) + 1}
+		return nil
+	})
+	if err != nil {
+		return Reservation{}, WaitlistPosition{}, err
+	}
+
+	b.publish(events)
+	return res, pos, nil
+}
+
+// MyWaitlist liste les inscriptions d'un utilisateur avec leur rang actuel.
+func (b *BookingService) MyWaitlist(email string) ([]WaitlistPosition, error) {
+	var out []WaitlistPosition
+	err := b.repo.WithTx(func(tx Repository) error {
+		entries, err := tx.ListWaitlistByEmail(email)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range entries {
+			queue, err := tx.ListWaitlistBySlot(e.SlotID)
+			if err != nil {
+				return err
+			}
+			for i, w := range queue {
+				if w.ID == e.ID {
+					out = append(out, WaitlistPosition{WaitlistEntry: e, Position: i + 1})
+					break
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// LeaveWaitlist retire une inscription ; seul son propriétaire peut le faire.
+func (b *BookingService) LeaveWaitlist(id ID, userEmail string) error {
+	return b.repo.WithTx(func(tx Repository) error {
+		entry, err := tx.GetWaitlistEntry(id)
+		if err != nil {
+			return err
+		}
+		if entry.UserEmail != userEmail {
+			return ErrWaitlistNotFound
+		}
+		return tx.DeleteWaitlistEntry(id)
+	})
+}
+
+// promoteWaitlist attribue les places libres du créneau aux premiers de la
+// liste d'attente, dans l'ordre d'arrivée, et renvoie les événements à
+// publier. Un groupe trop grand pour les places libres garde son rang :
+// ceux qui le suivent attendent aussi. Un inscrit qui a depuis atteint une
+// limite de réservation (voir checkLimits) ou qui retient déjà des places
+// sur le créneau est passé mais reste inscrit.
+// Rien n'est fait pour un créneau déjà passé ou trop proche (MinLeadTime).
+func (b *BookingService) promoteWaitlist(tx Repository, slot Slot, svc Service) ([]Event, error) {
+	if !slot.Datetime.After(b.now()) || b.checkLeadTime(slot) != nil {
+		return nil, nil
+	}
+
+	occ, err := b.slotOccupancy(tx, slot.ID)
+	if err != nil {
+		return nil, err
+	}
+	free := slot.Capacity - occ.used()
+	if free <= 0 {
+		return nil, nil
+	}
+
+	queue, err := tx.ListWaitlistBySlot(slot.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	for _, w := range queue {
+		if w.SeatCount() > free {
+			break
+		}
+		if _, held := occ.holdOf(w.UserEmail); held {
+			continue
+		}
+		if err := b.checkLimits(tx, svc, slot, w.UserEmail, ""); err != nil {
+			if isLimitError(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		if err := tx.DeleteWaitlistEntry(w.ID); err != nil {
+			return nil, err
+		}
+
+		now := b.now()
+		res, err := tx.CreateReservation(Reservation{
+			SlotID:      slot.ID,
+			UserEmail:   w.UserEmail,
+			Seats:       w.SeatCount(),
+			Status:      StatusConfirmed,
+			CreatedAt:   now,
+			ConfirmedAt: &now,
+		})
+		if err != nil {
+			return nil, err
+		}
+		free -= res.Seats
+
+		events = append(events, Event{Type: EventWaitlistPromoted, Reservation: res, Slot: slot, Service: svc})
+	}
+
+	return events, nil
+}
+
+// leaveWaitlist retire l'utilisateur de la file d'un créneau (après une
+// réservation directe, par exemple). Ne fait rien s'il n'y était pas.
+func leaveWaitlist(tx Repository, slotID ID, userEmail string) error {
+	queue, err := tx.ListWaitlistBySlot(slotID)
+	if err != nil {
+		return err
+	}
+	for _, w := range queue {
+		if w.UserEmail == userEmail {
+			return tx.DeleteWaitlistEntry(w.ID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,465 @@
+package http
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gestionsvc/internal/mail"
+	"gestionsvc/internal/services"
+)
+
+//
+// ---------- Configuration de l'authentification ----------
+//
+
+// AuthConfig regroupe les paramètres des sessions.
+// • Secret = clé HMAC qui signe les jetons de session et les liens de connexion (à garder secrète)
+// • SessionTTL = durée de validité d'une session
+// • SecureCookie = cookie envoyé uniquement en HTTPS
+// • Mailer = envoi des liens de connexion (nil = connexion par lien désactivée)
+// • BaseURL = adresse publique du serveur, utilisée dans les liens (ex : "https://rdv.example.com")
+// • LoginLinkTTL = durée de validité d'un lien de connexion
+type AuthConfig struct {
+	Secret       []byte
+	SessionTTL   time.Duration
+	SecureCookie bool
+	Mailer       mail.Mailer
+	BaseURL      string
+	LoginLinkTTL time.Duration
+}
+
+// sessionCookie = nom du cookie qui transporte le jeton de session.
+const sessionCookie = "session"
+
+// ctxKey évite les collisions de clés dans le contexte de la requête.
+type ctxKey int
+
+// userKey = clé du contexte contenant l'utilisateur connecté (services.User).
+const userKey ctxKey = 0
+
+//
+// ---------- Jetons signés ----------
+//
+
+// signToken fabrique le jeton envoyé au client : "<id>.<signature>".
+// La signature HMAC-SHA256 empêche de fabriquer un jeton sans connaître le secret.
+func (s *Server) signToken(id services.ID) string {
+	mac := hmac.New(sha256.New, s.auth.Secret)
+	mac.Write([]byte(id))
+	return string(id) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// parseToken vérifie la signature d'un jeton et renvoie l'ID de session.
+func (s *Server) parseToken(token string) (services.ID, bool) {
+	id, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", false
+	}
+
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return "", false
+	}
+
+	mac := hmac.New(sha256.New, s.auth.Secret)
+	mac.Write([]byte(id))
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return "", false
+	}
+
+	return services.ID(id), true
+}
+
+// requestToken lit le jeton dans le cookie de session,
+// ou dans l'en-tête "Authorization: Bearer ..." pour les clients API.
+func requestToken(r *http.Request) string {
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		return c.Value
+	}
+
+	if h := r.Header.Get("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimPrefix(h, "Bearer ")
+	}
+
+	return ""
+}
+
+//
+// ---------- Middleware ----------
+//
+
+// authenticate résout l'utilisateur connecté avant d'appeler next.
+//
+// Une requête sans jeton valide (absent, falsifié, session expirée ou
+// fermée) continue en anonyme : c'est chaque handler qui décide si
+// une connexion est nécessaire.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, ok := s.parseToken(requestToken(r)); ok {
+			if u, err := s.Booking.ResolveSession(id); err == nil {
+				r = r.WithContext(context.WithValue(r.Context(), userKey, u))
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// currentUser renvoie l'utilisateur connecté (Email vide si anonyme).
+func currentUser(r *http.Request) services.User {
+	u, _ := r.Context().Value(userKey).(services.User)
+	return u
+}
+
+// currentEmail renvoie l'email de l'utilisateur connecté ("" si anonyme).
+func currentEmail(r *http.Request) string {
+	return currentUser(r).Email
+}
+
+// requireLogin renvoie l'email connecté, ou répond 401 si la requête est anonyme.
+func requireLogin(w http.ResponseWriter, r *http.Request) (string, bool) {
+	email := currentEmail(r)
+	if email == "" {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "login required"})
+		return "", false
+	}
+	return email, true
+}
+
+// require n'appelle next que si l'utilisateur connecté possède perm :
+// 401 pour une requête anonyme, 403 si le rôle n'a pas la permission.
+//
+// Pour les routes qui portent sur un service précis, le handler vérifie
+// ensuite la propriété du service (voir authorizeService).
+func (s *Server) require(perm services.Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := currentUser(r)
+		if u.Email == "" {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "login required"})
+			return
+		}
+		if !u.Role.Can(perm) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": services.ErrForbidden.Error()})
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// authorizeService vérifie que l'utilisateur connecté peut exercer perm
+// sur le service ; sinon répond 403 (ou 404 si le service n'existe pas).
+func (s *Server) authorizeService(w http.ResponseWriter, r *http.Request, perm services.Permission, svcID services.ID) bool {
+	if err := s.Booking.CanManageService(currentUser(r), perm, svcID); err != nil {
+		writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// authorizeSlot vérifie que l'utilisateur connecté peut exercer perm
+// sur le service auquel appartient le créneau.
+func (s *Server) authorizeSlot(w http.ResponseWriter, r *http.Request, perm services.Permission, slotID services.ID) bool {
+	if err := s.Booking.CanManageSlot(currentUser(r), perm, slotID); err != nil {
+		writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// authorizeReservation vérifie que l'utilisateur connecté peut exercer perm
+// sur le service du créneau réservé.
+func (s *Server) authorizeReservation(w http.ResponseWriter, r *http.Request, perm services.Permission, resID services.ID) bool {
+	if err := s.Booking.CanManageReservation(currentUser(r), perm, resID); err != nil {
+		writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// setSessionCookie envoie le cookie de session au navigateur.
+func (s *Server) setSessionCookie(w http.ResponseWriter, token string, expires time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   s.auth.SecureCookie,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// clearSessionCookie demande au navigateur d'oublier le cookie de session.
+func (s *Server) clearSessionCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   s.auth.SecureCookie,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+//
+// ---------- Routes /auth ----------
+//
+
+// POST /auth/register
+//
+// Body JSON : { "email": "...", "password": "..." }
+// Crée le compte puis ouvre directement une session.
+func (s *Server) register(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var in struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+
+	if err := readJSON(r, &in); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad json"})
+		return
+	}
+
+	u, err := s.Booking.Register(in.Email, in.Password)
+	if errors.Is(err, services.ErrUserExists) {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	s.startSession(w, u)
+}
+
+// POST /auth/login
+//
+// Body JSON : { "email": "...", "password": "..." }
+// Réponse : { "email", "role", "permissions", "token", "expiresAt" } + cookie de session.
+//
+// Sans mot de passe (et si un Mailer est configuré), un lien de connexion
+// à usage unique est envoyé par email : réponse 202 { "status": "login link sent" }.
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var in struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+
+	if err := readJSON(r, &in); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad json"})
+		return
+	}
+
+	if in.Email != "" && in.Password == "" && s.auth.Mailer != nil {
+		s.sendLoginLink(w, in.Email)
+		return
+	}
+
+	if in.Email == "" || in.Password == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "email and password required"})
+		return
+	}
+
+	u, err := s.Booking.Authenticate(in.Email, in.Password)
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		return
+	}
+
+	s.startSession(w, u)
+}
+
+// sendLoginLink crée un lien de connexion et l'envoie par email.
+//
+// La réponse est la même que le compte existe ou non : elle ne révèle
+// pas quels emails sont inscrits.
+func (s *Server) sendLoginLink(w http.ResponseWriter, email string) {
+	link, err := s.Booking.CreateLoginLink(email, s.auth.LoginLinkTTL)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	verify := strings.TrimRight(s.auth.BaseURL, "/") + "/auth/verify?token=" + url.QueryEscape(s.signToken(link.ID))
+
+	err = s.auth.Mailer.Send(mail.Message{
+		To:      link.Email,
+		Subject: "Votre lien de connexion",
+		Body: fmt.Sprintf("Bonjour,\n\n"+
+			"Cliquez sur ce lien pour vous connecter (valable %d minutes, utilisable une seule fois) :\n\n"+
+			"%s\n\n"+
+			"Si vous n'avez pas demandé à vous connecter, ignorez simplement ce message.\n",
+			int(s.auth.LoginLinkTTL.Minutes()), verify),
+	})
+	if err != nil {
+		log.Printf("login link for %s: %v", link.Email, err)
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": "could not send login link"})
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "login link sent"})
+}
+
+// verifyPage = page ouverte par le lien reçu par email. Le bouton envoie
+// le jeton en POST : seul ce POST consomme le lien.
+var verifyPage = template.Must(template.New("verify").Parse(`<!doctype html>
+<html lang="fr">
+<head><meta charset="utf-8"><title>Connexion</title></head>
+<body>
+{{if .Error}}
+<p>Ce lien de connexion n'est plus valable ({{.Error}}). Demandez-en un nouveau depuis la page de connexion.</p>
+<p><a href="/">Retour à l'accueil</a></p>
+{{else}}
+<form method="post" action="/auth/verify">
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">Se connecter</button>
+</form>
+{{end}}
+</body>
+</html>
+`))
+
+// writeVerifyPage affiche la page de confirmation (ou d'erreur si msg != "").
+func writeVerifyPage(w http.ResponseWriter, code int, token, msg string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	_ = verifyPage.Execute(w, struct{ Token, Error string }{token, msg})
+}
+
+// GET /auth/verify?token=...
+// POST /auth/verify (formulaire : token=...)
+//
+// Le GET n'affiche qu'une page de confirmation : un antivirus ou un
+// aperçu de messagerie qui suit le lien ne le consomme pas.
+// Le POST échange le lien contre une session (cookie), puis redirige
+// vers l'accueil. Lien falsifié, expiré ou déjà utilisé → 401.
+func (s *Server) verify(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		token := r.URL.Query().Get("token")
+		if _, ok := s.parseToken(token); !ok {
+			writeVerifyPage(w, http.StatusUnauthorized, "", services.ErrLoginLinkNotFound.Error())
+			return
+		}
+		writeVerifyPage(w, http.StatusOK, token, "")
+
+	case http.MethodPost:
+		id, ok := s.parseToken(r.PostFormValue("token"))
+		if !ok {
+			writeVerifyPage(w, http.StatusUnauthorized, "", services.ErrLoginLinkNotFound.Error())
+			return
+		}
+
+		u, err := s.Booking.ConsumeLoginLink(id)
+		if errors.Is(err, services.ErrLoginLinkNotFound) || errors.Is(err, services.ErrLoginLinkExpired) {
+			writeVerifyPage(w, http.StatusUnauthorized, "", err.Error())
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+
+		if _, _, err := s.openSession(w, u); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// openSession ouvre une session pour u et pose le cookie.
+func (s *Server) openSession(w http.ResponseWriter, u services.User) (services.Session, string, error) {
+	sess, err := s.Booking.OpenSession(u.Email, s.auth.SessionTTL)
+	if err != nil {
+		return services.Session{}, "", err
+	}
+
+	token := s.signToken(sess.ID)
+	s.setSessionCookie(w, token, sess.ExpiresAt)
+
+	return sess, token, nil
+}
+
+// startSession ouvre une session, pose le cookie et renvoie le jeton.
+func (s *Server) startSession(w http.ResponseWriter, u services.User) {
+	sess, token, err := s.openSession(w, u)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"email":       sess.UserEmail,
+		"role":        u.Role.OrDefault(),
+		"permissions": u.Role.Permissions(),
+		"token":       token,
+		"expiresAt":   sess.ExpiresAt,
+	})
+}
+
+// POST /auth/logout
+//
+// Ferme la session courante : le jeton devient inutilisable.
+func (s *Server) logout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if id, ok := s.parseToken(requestToken(r)); ok {
+		_ = s.Booking.CloseSession(id)
+	}
+
+	s.clearSessionCookie(w)
+	writeJSON(w, http.StatusOK, map[string]string{"status": "logged out"})
+}
+
+// GET /auth/me
+//
+// Retourne l'utilisateur connecté, son rôle et ses permissions
+// (401 si aucune session valide).
+func (s *Server) me(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	if _, ok := requireLogin(w, r); !ok {
+		return
+	}
+	u := currentUser(r)
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"email":       u.Email,
+		"role":        u.Role.OrDefault(),
+		"permissions": u.Role.Permissions(),
+	})
+}
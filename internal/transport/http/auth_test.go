@@ -0,0 +1,115 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"gestionsvc/internal/repository"
+	"gestionsvc/internal/services"
+)
+
+// newVerifyServer crée un serveur sur un JSONStore temporaire, avec une
+// horloge réglable pour faire expirer les liens.
+func newVerifyServer(t *testing.T) (*Server, *time.Time) {
+	t.Helper()
+	repo, err := repository.NewJSONStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2099, 1, 5, 9, 0, 0, 0, time.UTC)
+	b := services.NewBookingService(repo, services.WithClock(func() time.Time { return now }))
+	s := NewServer(b, AuthConfig{
+		Secret:       []byte("test-secret"),
+		SessionTTL:   time.Hour,
+		LoginLinkTTL: 15 * time.Minute,
+	})
+	return s, &now
+}
+
+// postVerify envoie le formulaire de la page de confirmation.
+func postVerify(s *Server, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/auth/verify", strings.NewReader(url.Values{"token": {token}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	s.Mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestVerifyGetDoesNotConsume(t *testing.T) {
+	s, _ := newVerifyServer(t)
+	link, err := s.Booking.CreateLoginLink("alice@example.com", 15*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := s.signToken(link.ID)
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		s.Mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth/verify?token="+url.QueryEscape(token), nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET #%d: status %d, want 200", i+1, rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), `method="post"`) || len(rec.Result().Cookies()) != 0 {
+			t.Fatalf("GET #%d: want a confirmation form and no session cookie", i+1)
+		}
+	}
+
+	rec := postVerify(s, token)
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("POST after GET: status %d, want 303", rec.Code)
+	}
+	if len(rec.Result().Cookies()) != 1 || rec.Result().Cookies()[0].Name != sessionCookie {
+		t.Fatalf("POST: cookies = %v, want a session cookie", rec.Result().Cookies())
+	}
+}
+
+func TestVerifyRejectsBadLinks(t *testing.T) {
+	tests := []struct {
+		name  string
+		token func(t *testing.T, s *Server, now *time.Time, id services.ID) string
+	}{
+		{"tampered signature", func(t *testing.T, s *Server, _ *time.Time, id services.ID) string {
+			return s.signToken(id) + "x"
+		}},
+		{"tampered id", func(t *testing.T, s *Server, _ *time.Time, id services.ID) string {
+			_, sig, _ := strings.Cut(s.signToken(id), ".")
+			return string(id) + "0." + sig
+		}},
+		{"signed unknown id", func(t *testing.T, s *Server, _ *time.Time, _ services.ID) string {
+			return s.signToken("lnk_unknown")
+		}},
+		{"expired", func(t *testing.T, s *Server, now *time.Time, id services.ID) string {
+			*now = now.Add(15 * time.Minute)
+			return s.signToken(id)
+		}},
+		{"already used", func(t *testing.T, s *Server, _ *time.Time, id services.ID) string {
+			token := s.signToken(id)
+			if rec := postVerify(s, token); rec.Code != http.StatusSeeOther {
+				t.Fatalf("first use: status %d, want 303", rec.Code)
+			}
+			return token
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, now := newVerifyServer(t)
+			link, err := s.Booking.CreateLoginLink("alice@example.com", 15*time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := postVerify(s, tt.token(t, s, now, link.ID))
+			if rec.Code != http.StatusUnauthorized {
+				t.Fatalf("status %d, want 401", rec.Code)
+			}
+			if len(rec.Result().Cookies()) != 0 {
+				t.Fatalf("cookies = %v, want none", rec.Result().Cookies())
+			}
+		})
+	}
+}
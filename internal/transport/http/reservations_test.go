@@ -0,0 +1,63 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gestionsvc/internal/services"
+)
+
+// bearer ouvre une session pour email et renvoie l'en-tête Authorization.
+func bearer(t *testing.T, s *Server, email string) string {
+	t.Helper()
+	if _, err := s.Booking.Register(email, "password123"); err != nil {
+		t.Fatal(err)
+	}
+	sess, err := s.Booking.OpenSession(email, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + s.signToken(sess.ID)
+}
+
+// Annuler une réservation inconnue répond 404, celle d'un autre client 403.
+func TestCancelReservationStatus(t *testing.T) {
+	s, now := newVerifyServer(t)
+	alice, bob := bearer(t, s, "alice@example.com"), bearer(t, s, "bob@example.com")
+
+	svc, err := s.Booking.CreateService(services.Service{Name: "Yoga", Duration: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slot, err := s.Booking.AddSlot(svc.ID, now.AddDate(0, 0, 1).Format(time.RFC3339), 2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := s.Booking.Book(slot.ID, "alice@example.com", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		auth string
+		id   services.ID
+		want int
+	}{
+		{"unknown reservation", alice, "res_unknown", http.StatusNotFound},
+		{"someone else's reservation", bob, res.ID, http.StatusForbidden},
+		{"own reservation", alice, res.ID, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodDelete, "/reservations/"+string(tt.id), nil)
+		req.Header.Set("Authorization", tt.auth)
+		rec := httptest.NewRecorder()
+		s.Mux.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d (%s)", tt.name, rec.Code, tt.want, rec.Body)
+		}
+	}
+}
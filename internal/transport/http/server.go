@@ -2,9 +2,12 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"gestionsvc/internal/services"
 )
@@ -15,37 +18,64 @@ import (
 
 // Server regroupe :
 // - un ServeMux pour enregistrer les routes HTTP,
-// - un BookingService qui contient la logique métier.
+// - un BookingService qui contient la logique métier,
+// - la configuration des sessions (voir auth.go).
 type Server struct {
 	Mux     *http.ServeMux
 	Booking *services.BookingService
+	auth    AuthConfig
 }
 
 // NewServer configure les routes de l'API et retourne un Server prêt à être utilisé.
-func NewServer(b *services.BookingService) *Server {
+func NewServer(b *services.BookingService, auth AuthConfig) *Server {
 	s := &Server{
 		Mux:     http.NewServeMux(),
 		Booking: b,
+		auth:    auth,
 	}
 
-	// Auth simulée
-	s.Mux.HandleFunc("/auth/login", s.login)
+	// Authentification (auth.go)
+	s.handle("/auth/register", s.register) // POST /auth/register
+	s.handle("/auth/login", s.login)       // POST /auth/login
+	s.handle("/auth/logout", s.logout)     // POST /auth/logout
+	s.handle("/auth/me", s.me)             // GET /auth/me
+	s.handle("/auth/verify", s.verify)     // GET /auth/verify?token=... (page), POST /auth/verify
 
 	// Services
-	s.Mux.HandleFunc("/services", s.listServices)      // GET /services
-	s.Mux.HandleFunc("/services/", s.serviceSubroutes) // GET /services/:id/slots
+	s.handle("/services", s.listServices)      // GET /services
+	s.handle("/services/", s.serviceSubroutes) // GET /services/:id, GET /services/:id/slots
+	s.handle("/availability", s.availability)  // GET /availability?from=&to=&service=&minSeats=
 
-	// Administration
-	s.Mux.HandleFunc("/admin/services", s.adminCreateService)      // POST /admin/services
-	s.Mux.HandleFunc("/admin/services/", s.adminServiceSubroutes)  // POST /admin/services/:id/slots
+	// Administration : chaque route exige une permission (voir services/roles.go)
+	s.handle("/admin/services", s.require(services.PermManageServices, s.adminCreateService))          // POST /admin/services
+	s.handle("/admin/services/", s.require(services.PermManageSlots, s.adminServiceSubroutes))         // PUT/PATCH/DELETE /admin/services/:id, POST /admin/services/:id/slots
+	s.handle("/admin/slots/", s.require(services.PermManageSlots, s.adminSlotSubroutes))               // PUT/PATCH/DELETE /admin/slots/:id, GET /admin/slots/:id/reservations
+	s.handle("/admin/reservations/", s.require(services.PermManageSlots, s.adminReservationSubroutes)) // PUT /admin/reservations/:id/status
+	s.handle("/admin/resources", s.require(services.PermManageSlots, s.adminResourcesRoot))            // GET/POST /admin/resources
+	s.handle("/admin/resources/", s.require(services.PermManageResources, s.adminResourceSubroutes))   // PUT/DELETE /admin/resources/:id
+	s.handle("/admin/integrity", s.require(services.PermIntegrity, s.adminIntegrity))                  // GET /admin/integrity
+	s.handle("/admin/integrity/repair", s.require(services.PermIntegrity, s.adminIntegrity))           // POST /admin/integrity/repair
+	s.handle("/admin/users", s.require(services.PermManageUsers, s.adminListUsers))                    // GET /admin/users
+	s.handle("/admin/users/", s.require(services.PermManageUsers, s.adminUserSubroutes))               // PUT /admin/users/:email/role
 
 	// Réservations
-	s.Mux.HandleFunc("/reservations", s.reservationsRoot) // POST /reservations
-	s.Mux.HandleFunc("/reservations/", s.reservationsSub) // GET /reservations/me, DELETE /reservations/:id
+	s.handle("/reservations", s.require(services.PermBook, s.reservationsRoot)) // POST /reservations
+	s.handle("/reservations/", s.require(services.PermBook, s.reservationsSub)) // GET /reservations/me, PATCH|DELETE /reservations/:id, DELETE /reservations/waitlist/:id
+	s.handle("/waitlist/me", s.require(services.PermBook, s.myWaitlist))        // GET /waitlist/me
+
+	// Places retenues pendant la saisie
+	s.handle("/holds", s.require(services.PermBook, s.holdsRoot)) // POST /holds
+	s.handle("/holds/", s.require(services.PermBook, s.holdsSub)) // POST /holds/:id/confirm, DELETE /holds/:id
 
 	return s
 }
 
+// handle enregistre une route : l'utilisateur connecté est résolu
+// par le middleware authenticate avant l'appel du handler.
+func (s *Server) handle(pattern string, h http.HandlerFunc) {
+	s.Mux.Handle(pattern, s.authenticate(h))
+}
+
 //
 // ---------- Helpers génériques JSON / Auth ----------
 //
@@ -72,43 +102,41 @@ func readJSON(r *http.Request, v any) error {
 	return json.Unmarshal(b, v)
 }
 
-// currentEmail récupère l'email courant depuis l'en-tête HTTP "X-User-Email".
-func currentEmail(r *http.Request) string {
-	return r.Header.Get("X-User-Email")
-}
-
-// isAdmin vérifie si l'email correspond à l'administrateur.
-func isAdmin(email string) bool {
-	return email == "admin@example.com"
-}
-
-//
-// ---------- Auth simulée ----------
-//
-
-// POST /auth/login
-//
-// Le front envoie un JSON { "email": "..." }.
-// Ici on ne gère pas de session réelle : on renvoie juste l'email,
-// et le front le stocke côté navigateur (localStorage).
-func (s *Server) login(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
-	}
-
-	var in struct {
-		Email string `json:"email"`
-	}
-
-	_ = readJSON(r, &in)
-
-	if in.Email == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "email required"})
-		return
+// errorStatus choisit le code HTTP correspondant à une erreur métier.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrServiceNotFound),
+		errors.Is(err, services.ErrSlotNotFound),
+		errors.Is(err, services.ErrReservationNotFound),
+		errors.Is(err, services.ErrUserNotFound),
+		errors.Is(err, services.ErrWaitlistNotFound),
+		errors.Is(err, services.ErrHoldNotFound),
+		errors.Is(err, services.ErrResourceNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrHoldExpired):
+		return http.StatusGone
+	case errors.Is(err, services.ErrForbidden),
+		errors.Is(err, services.ErrNotYourReservation):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrServiceInUse),
+		errors.Is(err, services.ErrSlotInUse),
+		errors.Is(err, services.ErrCapacityTooLow),
+		errors.Is(err, services.ErrInvalidTransition),
+		errors.Is(err, services.ErrCancelDeadline),
+		errors.Is(err, services.ErrRescheduleLimit),
+		errors.Is(err, services.ErrSlotOverlap),
+		errors.Is(err, services.ErrResourceInUse),
+		errors.Is(err, services.ErrResourceBusy),
+		errors.Is(err, services.ErrOutsideHours),
+		errors.Is(err, services.ErrBlackout),
+		errors.Is(err, services.ErrTooManyReservations),
+		errors.Is(err, services.ErrServiceLimit),
+		errors.Is(err, services.ErrDailyLimit),
+		errors.Is(err, services.ErrTooLate):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
 	}
-
-	writeJSON(w, http.StatusOK, map[string]string{"email": in.Email})
 }
 
 //
@@ -133,10 +161,15 @@ func (s *Server) listServices(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, list)
 }
 
-// GET /services/:id/slots
+// GET /services/:id
+// GET /services/:id/slots[?full=false][&past=false]
 //
 // Gère les sous-routes de /services/.
 // Exemple d'URL attendue : /services/svc_123/slots
+//
+// Les créneaux sont triés par date, avec leur début et leur fin ("start", "end")
+// et leurs places : "booked", "remaining", "full".
+// full=false retire les créneaux complets, past=false ceux déjà commencés.
 func (s *Server) serviceSubroutes(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -144,13 +177,30 @@ func (s *Server) serviceSubroutes(w http.ResponseWriter, r *http.Request) {
 	}
 
 	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	// On attend : [ "services", ":id" ]
+	if len(parts) == 2 && parts[0] == "services" {
+		svc, err := s.Booking.GetService(services.ID(parts[1]))
+		if err != nil {
+			writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, svc)
+		return
+	}
+
 	// On attend : [ "services", ":id", "slots" ]
 	if len(parts) == 3 && parts[0] == "services" && parts[2] == "slots" {
 		svcID := services.ID(parts[1])
 
-		slots, err := s.Booking.ListSlotsByService(svcID)
+		q := r.URL.Query()
+		slots, err := s.Booking.ListSlotsByService(svcID, services.SlotFilter{
+			HideFull: q.Get("full") == "false",
+			HidePast: q.Get("past") == "false",
+		})
 		if err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
 			return
 		}
 
@@ -161,30 +211,215 @@ func (s *Server) serviceSubroutes(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNotFound)
 }
 
+// GET /availability?from=2027-01-04&to=2027-01-10&service=svc_...&minSeats=2
+//
+// Créneaux à venir où il reste des places, tous services confondus, triés par
+// date, avec "remaining" (places libres) et "serviceName". Tous les paramètres
+// sont facultatifs : de maintenant à 30 jours, au moins une place.
+// "from" / "to" : jour (inclus), heure locale ou RFC3339.
+func (s *Server) availability(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	query := services.AvailabilityQuery{
+		From:      q.Get("from"),
+		To:        q.Get("to"),
+		ServiceID: services.ID(q.Get("service")),
+	}
+	if v := q.Get("minSeats"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid minSeats"})
+			return
+		}
+		query.MinSeats = n
+	}
+
+	list, err := s.Booking.SearchAvailability(query)
+	if err != nil {
+		writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
 //
 // ---------- Administration ----------
 //
 
 // POST /admin/services
 //
-// Crée un nouveau service.
+// Crée un nouveau service. Body JSON : { "name", "description", "duration", "maxPartySize", "owner",
+// "cancellation": { "noticeMinutes": 1440, "maxReschedules": 2, "allowLate": true }, "noOverlap": true,
+// "calendar": { "hours": [{ "days": ["MO","TU"], "open": "09:00", "close": "18:00" }],
+// "blackouts": [{ "from": "2027-08-01", "until": "2027-08-15", "reason": "Congés" }], "frenchHolidays": true },
+// "timeZone": "Europe/Paris" }
 //
-// Nécessite l'en-tête X-User-Email = admin@example.com
+// Permission services:manage. Un manager devient automatiquement
+// propriétaire du service ; un admin peut désigner "owner".
 func (s *Server) adminCreateService(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
 
-	if !isAdmin(currentEmail(r)) {
-		writeJSON(w, http.StatusForbidden, map[string]string{"error": "admin only"})
+	var in struct {
+		Name         string                      `json:"name"`
+		Description  string                      `json:"description"`
+		Duration     int                         `json:"duration"`
+		MaxPartySize int                         `json:"maxPartySize"`
+		Owner        string                      `json:"owner"`
+		Cancellation services.CancellationPolicy `json:"cancellation"`
+		NoOverlap    bool                        `json:"noOverlap"`
+		Calendar     *services.Calendar          `json:"calendar"`
+		TimeZone     string                      `json:"timeZone"`
+	}
+
+	if err := readJSON(r, &in); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad json"})
 		return
 	}
 
+	if u := currentUser(r); u.Role.OrDefault() != services.RoleAdmin {
+		in.Owner = u.Email
+	}
+
+	svc, err := s.Booking.CreateService(services.Service{
+		Name:         in.Name,
+		Description:  in.Description,
+		Duration:     in.Duration,
+		MaxPartySize: in.MaxPartySize,
+		Owner:        in.Owner,
+		Cancellation: in.Cancellation,
+		NoOverlap:    in.NoOverlap,
+		Calendar:     in.Calendar,
+		TimeZone:     in.TimeZone,
+	})
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, svc)
+}
+
+// PUT|PATCH|DELETE /admin/services/:id
+// POST /admin/services/:id/slots
+// POST /admin/services/:id/slots/recurring
+// GET /admin/services/:id/conflicts
+//
+// Gère les sous-routes de /admin/services/.
+//
+// Modifier ou supprimer le service exige services:manage, ajouter un créneau
+// slots:manage ; un manager n'agit que sur les services dont il est propriétaire.
+func (s *Server) adminServiceSubroutes(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "admin" || parts[1] != "services" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	svcID := services.ID(parts[2])
+
+	// On attend : [ "admin", "services", ":id" ]
+	if len(parts) == 3 {
+		if r.Method != http.MethodPut && r.Method != http.MethodPatch && r.Method != http.MethodDelete {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.authorizeService(w, r, services.PermManageServices, svcID) {
+			return
+		}
+
+		if r.Method == http.MethodDelete {
+			s.adminDeleteService(w, r, svcID)
+		} else {
+			s.adminUpdateService(w, r, svcID)
+		}
+		return
+	}
+
+	// On attend : [ "admin", "services", ":id", "slots" ]
+	if len(parts) == 4 && parts[3] == "slots" {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.authorizeService(w, r, services.PermManageSlots, svcID) {
+			return
+		}
+		s.adminAddSlot(w, r, svcID)
+		return
+	}
+
+	// On attend : [ "admin", "services", ":id", "conflicts" ]
+	if len(parts) == 4 && parts[3] == "conflicts" {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.authorizeService(w, r, services.PermManageSlots, svcID) {
+			return
+		}
+		s.adminCalendarConflicts(w, r, svcID)
+		return
+	}
+
+	// On attend : [ "admin", "services", ":id", "slots", "recurring" ]
+	if len(parts) == 5 && parts[3] == "slots" && parts[4] == "recurring" {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.authorizeService(w, r, services.PermManageSlots, svcID) {
+			return
+		}
+		s.adminAddRecurringSlots(w, r, svcID)
+		return
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+}
+
+// GET /admin/services/:id/conflicts
+//
+// Créneaux à venir du service qui tombent pendant une fermeture ou hors des
+// horaires d'ouverture, avec le nombre de réservations actives à prévenir.
+func (s *Server) adminCalendarConflicts(w http.ResponseWriter, r *http.Request, svcID services.ID) {
+	conflicts, err := s.Booking.CalendarConflicts(svcID)
+	if err != nil {
+		writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, conflicts)
+}
+
+// PUT /admin/services/:id   → remplace tous les champs
+// PATCH /admin/services/:id → ne modifie que les champs envoyés
+//
+// Body JSON : { "name": "...", "description": "...", "duration": 30, "maxPartySize": 4,
+// "owner": "...", "cancellation": { "noticeMinutes": 1440, ... }, "noOverlap": true,
+// "calendar": { "hours": [...], "blackouts": [...], "frenchHolidays": true }, "timeZone": "Europe/Paris" }
+//
+// Quand "calendar" est envoyé, la réponse liste aussi dans "conflicts" les
+// créneaux à venir qui tombent désormais hors des horaires ou pendant une fermeture.
+//
+// "owner" n'est modifiable que par un admin ; absent, il est conservé (PUT compris).
+func (s *Server) adminUpdateService(w http.ResponseWriter, r *http.Request, svcID services.ID) {
 	var in struct {
-		Name        string `json:"name"`
-		Description string `json:"description"`
-		Duration    int    `json:"duration"`
+		Name         *string                      `json:"name"`
+		Description  *string                      `json:"description"`
+		Duration     *int                         `json:"duration"`
+		MaxPartySize *int                         `json:"maxPartySize"`
+		Owner        *string                      `json:"owner"`
+		Cancellation *services.CancellationPolicy `json:"cancellation"`
+		NoOverlap    *bool                        `json:"noOverlap"`
+		Calendar     *services.Calendar           `json:"calendar"`
+		TimeZone     *string                      `json:"timeZone"`
 	}
 
 	if err := readJSON(r, &in); err != nil {
@@ -192,58 +427,559 @@ func (s *Server) adminCreateService(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	svc, err := s.Booking.CreateService(in.Name, in.Description, in.Duration)
+	if in.Owner != nil && currentUser(r).Role.OrDefault() != services.RoleAdmin {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "only an admin can change the owner"})
+		return
+	}
+
+	// PUT : un champ absent est remis à sa valeur par défaut
+	if r.Method == http.MethodPut {
+		if in.Name == nil {
+			in.Name = new(string)
+		}
+		if in.Description == nil {
+			in.Description = new(string)
+		}
+		if in.Duration == nil {
+			in.Duration = new(int)
+		}
+		if in.MaxPartySize == nil {
+			in.MaxPartySize = new(int)
+		}
+		if in.Cancellation == nil {
+			in.Cancellation = new(services.CancellationPolicy)
+		}
+		if in.NoOverlap == nil {
+			in.NoOverlap = new(bool)
+		}
+		if in.Calendar == nil {
+			in.Calendar = new(services.Calendar)
+		}
+		if in.TimeZone == nil {
+			in.TimeZone = new(string)
+		}
+	}
+
+	svc, err := s.Booking.UpdateService(svcID, services.ServiceUpdate{
+		Name:         in.Name,
+		Description:  in.Description,
+		Duration:     in.Duration,
+		Owner:        in.Owner,
+		MaxPartySize: in.MaxPartySize,
+		Cancellation: in.Cancellation,
+		NoOverlap:    in.NoOverlap,
+		Calendar:     in.Calendar,
+		TimeZone:     in.TimeZone,
+	})
 	if err != nil {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
 		return
 	}
 
-	writeJSON(w, http.StatusOK, svc)
+	if in.Calendar == nil {
+		writeJSON(w, http.StatusOK, svc)
+		return
+	}
+
+	// Nouveau calendrier : signaler les créneaux à venir qui ne le respectent plus
+	conflicts, err := s.Booking.CalendarConflicts(svcID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		services.Service
+		Conflicts []services.CalendarConflict `json:"conflicts"`
+	}{svc, conflicts})
+}
+
+// DELETE /admin/services/:id[?cascade=true]
+//
+// Supprime un service et ses créneaux.
+// S'il reste des réservations à venir, la suppression est refusée (409)
+// sauf avec ?cascade=true : elles sont alors annulées et listées dans la réponse.
+func (s *Server) adminDeleteService(w http.ResponseWriter, r *http.Request, svcID services.ID) {
+	cascade := r.URL.Query().Get("cascade") == "true"
+
+	cancelled, err := s.Booking.DeleteService(svcID, cascade)
+	if err != nil {
+		writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+
+	if cancelled == nil {
+		cancelled = []services.Reservation{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":    "deleted",
+		"cancelled": cancelled,
+	})
 }
 
 // POST /admin/services/:id/slots
 //
 // Ajoute un créneau à un service existant.
-// Body JSON : { "datetime": "...", "capacity": 1 }
+// Body JSON : { "datetime": "...", "capacity": 1, "resources": ["rsc_..."] }
+// "datetime" : RFC3339 ("2027-01-04T09:00:00+01:00") ou heure locale du
+// service sans décalage ("2027-01-04T09:00") ; enregistré en UTC.
+// Une ressource déjà prise au même moment (tous services confondus) → 409.
+func (s *Server) adminAddSlot(w http.ResponseWriter, r *http.Request, svcID services.ID) {
+	var in struct {
+		Datetime  string        `json:"datetime"`
+		Capacity  int           `json:"capacity"`
+		Resources []services.ID `json:"resources"`
+	}
+
+	if err := readJSON(r, &in); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad json"})
+		return
+	}
+
+	slot, err := s.Booking.AddSlot(svcID, in.Datetime, in.Capacity, in.Resources)
+	if err != nil {
+		writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, slot)
+}
+
+// POST /admin/services/:id/slots/recurring
 //
-// Toujours réservé à l'admin.
-func (s *Server) adminServiceSubroutes(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+// Génère une série de créneaux. Body JSON, au choix :
+//   - règle iCalendar : { "start": "2027-01-04T09:00:00Z", "rrule": "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=10", "capacity": 4 }
+//   - modèle hebdomadaire : { "weekly": { "days": ["MO","WE"], "times": ["09:00"], "from": "2027-01-04", "until": "2027-03-31" }, "capacity": 4 }
+//
+// "resources" (facultatif) s'applique à tous les créneaux de la série.
+// Réponse : { "seriesId": "ser_...", "slots": [...], "skipped": [dates sautées, jours de fermeture] }
+func (s *Server) adminAddRecurringSlots(w http.ResponseWriter, r *http.Request, svcID services.ID) {
+	var in struct {
+		Start     string                   `json:"start"`
+		RRule     string                   `json:"rrule"`
+		Weekly    *services.WeeklyTemplate `json:"weekly"`
+		Capacity  int                      `json:"capacity"`
+		Resources []services.ID            `json:"resources"`
+	}
+
+	if err := readJSON(r, &in); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad json"})
+		return
+	}
+
+	slots, skipped, err := s.Booking.AddRecurringSlots(svcID, services.RecurrenceSpec{
+		Start:     in.Start,
+		RRule:     in.RRule,
+		Weekly:    in.Weekly,
+		Capacity:  in.Capacity,
+		Resources: in.Resources,
+	})
+	if err != nil {
+		writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+
+	if skipped == nil {
+		skipped = []time.Time{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"seriesId": slots[0].SeriesID,
+		"slots":    slots,
+		"skipped":  skipped,
+	})
+}
+
+// PUT|PATCH|DELETE /admin/slots/:id
+// GET /admin/slots/:id/reservations
+//
+// Modifie ou supprime un créneau, ou liste ses réservations (permission
+// slots:manage ; un manager n'agit que sur les créneaux de ses services).
+func (s *Server) adminSlotSubroutes(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	// On attend : [ "admin", "slots", ":id" ] ou [ "admin", "slots", ":id", "reservations" ]
+	if len(parts) < 3 || len(parts) > 4 || parts[0] != "admin" || parts[1] != "slots" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	slotID := services.ID(parts[2])
+
+	if len(parts) == 4 {
+		if parts[3] != "reservations" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.authorizeSlot(w, r, services.PermManageSlots, slotID) {
+			return
+		}
+		s.adminSlotReservations(w, r, slotID)
+		return
+	}
+
+	if r.Method != http.MethodPut && r.Method != http.MethodPatch && r.Method != http.MethodDelete {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
+	if !s.authorizeSlot(w, r, services.PermManageSlots, slotID) {
+		return
+	}
+
+	if r.Method == http.MethodDelete {
+		s.adminDeleteSlot(w, r, slotID)
+	} else {
+		s.adminUpdateSlot(w, r, slotID)
+	}
+}
 
-	if !isAdmin(currentEmail(r)) {
-		writeJSON(w, http.StatusForbidden, map[string]string{"error": "admin only"})
+// PUT /admin/slots/:id   → datetime et capacity obligatoires
+// PATCH /admin/slots/:id → seuls les champs envoyés sont modifiés
+//
+// Body JSON : { "datetime": "...", "capacity": 2, "resources": ["rsc_..."] }
+// "resources" absent = inchangé (PUT compris), [] = plus aucune ressource.
+//
+// Si la capacité devient inférieure au nombre de réservations, la requête
+// est refusée (409) sauf avec ?bump=true : les réservations en surnombre
+// sont annulées et listées dans "bumped".
+//
+// Avec ?scope=following, la modification s'applique aussi aux créneaux
+// suivants de la même série (décalés du même écart) ; la réponse contient
+// alors "slots" au lieu de "slot".
+func (s *Server) adminUpdateSlot(w http.ResponseWriter, r *http.Request, slotID services.ID) {
+	var in struct {
+		Datetime  *string        `json:"datetime"`
+		Capacity  *int           `json:"capacity"`
+		Resources *[]services.ID `json:"resources"`
+	}
+
+	if err := readJSON(r, &in); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad json"})
 		return
 	}
 
+	if r.Method == http.MethodPut && (in.Datetime == nil || in.Capacity == nil) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "datetime and capacity required"})
+		return
+	}
+
+	bump := r.URL.Query().Get("bump") == "true"
+	u := services.SlotUpdate{
+		Datetime:  in.Datetime,
+		Capacity:  in.Capacity,
+		Resources: in.Resources,
+	}
+
+	if r.URL.Query().Get("scope") == "following" {
+		slots, bumped, err := s.Booking.UpdateSeries(slotID, u, bump)
+		if err != nil {
+			writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+			return
+		}
+
+		if bumped == nil {
+			bumped = []services.Reservation{}
+		}
+
+		writeJSON(w, http.StatusOK, map[string]any{
+			"slots":  slots,
+			"bumped": bumped,
+		})
+		return
+	}
+
+	slot, bumped, err := s.Booking.UpdateSlot(slotID, u, bump)
+	if err != nil {
+		writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+
+	if bumped == nil {
+		bumped = []services.Reservation{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"slot":   slot,
+		"bumped": bumped,
+	})
+}
+
+// DELETE /admin/slots/:id[?cascade=true][&scope=following]
+//
+// Supprime un créneau. S'il reste des réservations à venir, refus (409)
+// sauf avec ?cascade=true : elles sont annulées et listées dans la réponse.
+// Avec scope=following, les créneaux suivants de la même série sont
+// supprimés aussi.
+func (s *Server) adminDeleteSlot(w http.ResponseWriter, r *http.Request, slotID services.ID) {
+	cascade := r.URL.Query().Get("cascade") == "true"
+
+	var (
+		cancelled []services.Reservation
+		err       error
+	)
+	if r.URL.Query().Get("scope") == "following" {
+		cancelled, err = s.Booking.DeleteSeries(slotID, cascade)
+	} else {
+		cancelled, err = s.Booking.DeleteSlot(slotID, cascade)
+	}
+	if err != nil {
+		writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+
+	if cancelled == nil {
+		cancelled = []services.Reservation{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":    "deleted",
+		"cancelled": cancelled,
+	})
+}
+
+// GET /admin/slots/:id/reservations
+//
+// Liste toutes les réservations du créneau, annulées comprises, avec leur
+// statut (pointage des présences).
+func (s *Server) adminSlotReservations(w http.ResponseWriter, r *http.Request, slotID services.ID) {
+	list, err := s.Booking.SlotReservations(slotID)
+	if err != nil {
+		writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+
+	if list == nil {
+		list = []services.Reservation{}
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+// PUT /admin/reservations/:id/status
+//
+// Change le statut d'une réservation (permission slots:manage ; un manager
+// n'agit que sur les réservations de ses services).
+//
+// Body JSON : { "status": "attended" }
+// Statuts : confirmed, cancelled (avant le créneau), attended, no_show
+// (une fois le créneau commencé). Transition interdite → 409.
+func (s *Server) adminReservationSubroutes(w http.ResponseWriter, r *http.Request) {
 	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
-	// On attend : [ "admin", "services", ":id", "slots" ]
-	if len(parts) == 4 && parts[0] == "admin" && parts[1] == "services" && parts[3] == "slots" {
-		svcID := services.ID(parts[2])
+	// On attend : [ "admin", "reservations", ":id", "status" ]
+	if len(parts) != 4 || parts[0] != "admin" || parts[1] != "reservations" || parts[3] != "status" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	resID := services.ID(parts[2])
 
-		var in struct {
-			Datetime string `json:"datetime"`
-			Capacity int    `json:"capacity"`
+	if !s.authorizeReservation(w, r, services.PermManageSlots, resID) {
+		return
+	}
+
+	var in struct {
+		Status string `json:"status"`
+	}
+
+	if err := readJSON(r, &in); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad json"})
+		return
+	}
+
+	status, err := services.ParseReservationStatus(in.Status)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	res, err := s.Booking.SetReservationStatus(resID, status)
+	if err != nil {
+		writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// GET /admin/integrity
+// POST /admin/integrity/repair
+//
+// Rapport des slots et réservations orphelins ; la variante /repair
+// les supprime et renvoie le rapport de ce qui a été nettoyé.
+func (s *Server) adminIntegrity(w http.ResponseWriter, r *http.Request) {
+	repair := strings.HasSuffix(r.URL.Path, "/repair")
+
+	if (repair && r.Method != http.MethodPost) || (!repair && r.Method != http.MethodGet) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	report, err := s.Booking.CheckIntegrity(repair)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// GET /admin/users
+//
+// Liste des comptes avec leur rôle (permission users:manage).
+func (s *Server) adminListUsers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	list, err := s.Booking.ListUsers()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	out := make([]services.User, 0, len(list))
+	for _, u := range list {
+		u.PasswordHash = "" // le hash ne quitte jamais le serveur
+		u.Role = u.Role.OrDefault()
+		out = append(out, u)
+	}
+
+	writeJSON(w, http.StatusOK, out)
+}
+
+// PUT /admin/users/:email/role
+//
+// Change le rôle d'un compte. Body JSON : { "role": "staff" }
+// Rôles : customer, staff, manager, admin.
+func (s *Server) adminUserSubroutes(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	// On attend : [ "admin", "users", ":email", "role" ]
+	if len(parts) != 4 || parts[0] != "admin" || parts[1] != "users" || parts[3] != "role" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPut {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var in struct {
+		Role string `json:"role"`
+	}
+
+	if err := readJSON(r, &in); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad json"})
+		return
+	}
+
+	role, err := services.ParseRole(in.Role)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	u, err := s.Booking.SetRole(parts[2], role)
+	if err != nil {
+		writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+
+	u.PasswordHash = ""
+	writeJSON(w, http.StatusOK, u)
+}
+
+//
+// ---------- Ressources ----------
+//
+
+// GET /admin/resources  → liste des ressources (slots:manage)
+// POST /admin/resources → crée une ressource (services:manage)
+func (s *Server) adminResourcesRoot(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		list, err := s.Booking.ListResources()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if list == nil {
+			list = []services.Resource{}
 		}
+		writeJSON(w, http.StatusOK, list)
+
+	case http.MethodPost:
+		s.require(services.PermManageServices, s.adminCreateResource)(w, r)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// POST /admin/resources
+//
+// Body JSON : { "name": "Salle 1", "kind": "room" } ; kind = staff, room ou equipment.
+func (s *Server) adminCreateResource(w http.ResponseWriter, r *http.Request) {
+	var in services.Resource
+	if err := readJSON(r, &in); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad json"})
+		return
+	}
+	in.ID = ""
+
+	res, err := s.Booking.CreateResource(in)
+	if err != nil {
+		writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+// PUT /admin/resources/:id    → renomme / change la nature. Body JSON : { "name": "...", "kind": "staff" }
+// DELETE /admin/resources/:id → supprime une ressource qu'aucun créneau n'utilise (sinon 409)
+//
+// Une ressource sert à tous les services : la modifier ou la supprimer
+// est réservé aux admins (resources:manage).
+func (s *Server) adminResourceSubroutes(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	// On attend : [ "admin", "resources", ":id" ]
+	if len(parts) != 3 || parts[0] != "admin" || parts[1] != "resources" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	id := services.ID(parts[2])
 
+	switch r.Method {
+	case http.MethodPut:
+		var in services.Resource
 		if err := readJSON(r, &in); err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad json"})
 			return
 		}
+		in.ID = id
 
-		slot, err := s.Booking.AddSlot(svcID, in.Datetime, in.Capacity)
+		res, err := s.Booking.UpdateResource(in)
 		if err != nil {
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
 			return
 		}
+		writeJSON(w, http.StatusOK, res)
 
-		writeJSON(w, http.StatusOK, slot)
-		return
-	}
+	case http.MethodDelete:
+		if err := s.Booking.DeleteResource(id); err != nil {
+			writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
 
-	w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
 }
 
 //
@@ -252,16 +988,21 @@ func (s *Server) adminServiceSubroutes(w http.ResponseWriter, r *http.Request) {
 
 // POST /reservations
 //
-// Crée une réservation pour l'utilisateur courant (X-User-Email).
+// Crée une réservation pour l'utilisateur connecté.
 //
-// Body JSON : { "slotId": "slt_123" }
+// Body JSON : { "slotId": "slt_123", "seats": 4, "waitlist": true }
+// "seats" = nombre de places (1 par défaut).
+// Avec "waitlist": true, un créneau complet inscrit l'utilisateur sur la
+// liste d'attente : réponse 202 avec sa position au lieu d'une erreur.
 func (s *Server) reservationsRoot(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodPost:
 		em := currentEmail(r)
 
 		var in struct {
-			SlotID services.ID `json:"slotId"`
+			SlotID   services.ID `json:"slotId"`
+			Seats    int         `json:"seats"`
+			Waitlist bool        `json:"waitlist"`
 		}
 
 		if err := readJSON(r, &in); err != nil {
@@ -269,9 +1010,23 @@ func (s *Server) reservationsRoot(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		res, err := s.Booking.Book(in.SlotID, em)
+		if in.Waitlist {
+			res, pos, err := s.Booking.BookOrWait(in.SlotID, em, in.Seats)
+			if err != nil {
+				writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+				return
+			}
+			if res.ID == "" {
+				writeJSON(w, http.StatusAccepted, pos)
+				return
+			}
+			writeJSON(w, http.StatusOK, res)
+			return
+		}
+
+		res, err := s.Booking.Book(in.SlotID, em, in.Seats)
 		if err != nil {
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
 			return
 		}
 
@@ -282,11 +1037,32 @@ func (s *Server) reservationsRoot(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// writeChangeError répond à un refus d'annulation ou de modification avec
+// le code code. Hors délai, la réponse est 409 et précise la date limite :
+// { "error": "...", "deadline": "2027-01-01T10:00:00Z", "noticeMinutes": 1440 }
+func writeChangeError(w http.ResponseWriter, err error, code int) {
+	var late *services.DeadlineError
+	if errors.As(err, &late) {
+		writeJSON(w, http.StatusConflict, map[string]any{
+			"error":         err.Error(),
+			"deadline":      late.Deadline,
+			"noticeMinutes": late.NoticeMinutes,
+		})
+		return
+	}
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
 // GET /reservations/me
+// PATCH /reservations/:id
 // DELETE /reservations/:id
+// DELETE /reservations/waitlist/:id
 //
-// - GET /reservations/me : toutes les réservations de l'utilisateur courant.
+// - GET /reservations/me : réservations de l'utilisateur courant (tableau JSON).
+// - PATCH /reservations/res_123 { "seats": 2 } : réduit le nombre de places.
+// - PATCH /reservations/res_123 { "slotId": "slt_456" } : déplace la réservation vers un autre créneau.
 // - DELETE /reservations/res_123 : annule une réservation (si encore valable).
+// - DELETE /reservations/waitlist/wl_123 : quitte une liste d'attente.
 func (s *Server) reservationsSub(w http.ResponseWriter, r *http.Request) {
 	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
 	if len(parts) < 2 || parts[0] != "reservations" {
@@ -304,24 +1080,169 @@ func (s *Server) reservationsSub(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if list == nil {
+			list = []services.Reservation{}
+		}
 		writeJSON(w, http.StatusOK, list)
 		return
 	}
 
+	// /reservations/waitlist/:id
+	if len(parts) == 3 && parts[1] == "waitlist" && r.Method == http.MethodDelete {
+		em := currentEmail(r)
+		id := services.ID(parts[2])
+
+		if err := s.Booking.LeaveWaitlist(id, em); err != nil {
+			writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+		return
+	}
+
+	// /reservations/:id (PATCH)
+	if len(parts) == 2 && r.Method == http.MethodPatch {
+		var in struct {
+			Seats  int         `json:"seats"`
+			SlotID services.ID `json:"slotId"`
+		}
+		if err := readJSON(r, &in); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad json"})
+			return
+		}
+		if in.SlotID != "" && in.Seats != 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "send either seats or slotId"})
+			return
+		}
+
+		var (
+			res services.Reservation
+			err error
+		)
+		if in.SlotID != "" {
+			res, err = s.Booking.Reschedule(services.ID(parts[1]), in.SlotID, currentEmail(r))
+		} else {
+			res, err = s.Booking.ReduceSeats(services.ID(parts[1]), currentEmail(r), in.Seats)
+		}
+		if err != nil {
+			writeChangeError(w, err, errorStatus(err))
+			return
+		}
+
+		writeJSON(w, http.StatusOK, res)
+		return
+	}
+
 	// /reservations/:id
 	if len(parts) == 2 && r.Method == http.MethodDelete {
 		em := currentEmail(r)
 		id := services.ID(parts[1])
 
 		if err := s.Booking.Cancel(id, em); err != nil {
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			writeChangeError(w, err, errorStatus(err))
 			return
 		}
 
-		writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+		writeJSON(w, http.StatusOK, map[string]string{"status": "cancelled"})
 		return
 	}
 
 	// Méthode non supportée sur cette sous-route
+	w.WriteHeader(http.StatusMethodNotAllowed)
+}
+
+// GET /waitlist/me
+//
+// Inscriptions en liste d'attente de l'utilisateur courant, avec leur position.
+func (s *Server) myWaitlist(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	list, err := s.Booking.MyWaitlist(currentEmail(r))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if list == nil {
+		list = []services.WaitlistPosition{}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+//
+// ---------- Places retenues ----------
+//
+
+// POST /holds
+//
+// Retient une place pour l'utilisateur connecté pendant qu'il termine
+// sa réservation. La place compte dans la capacité jusqu'à expiresAt.
+//
+// Body JSON : { "slotId": "slt_123", "seats": 2 }
+func (s *Server) holdsRoot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var in struct {
+		SlotID services.ID `json:"slotId"`
+		Seats  int         `json:"seats"`
+	}
+	if err := readJSON(r, &in); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad json"})
+		return
+	}
+
+	hold, err := s.Booking.HoldSeats(in.SlotID, currentEmail(r), in.Seats)
+	if err != nil {
+		writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, hold)
+}
+
+// POST /holds/:id/confirm
+// DELETE /holds/:id
+//
+// - POST /holds/hld_123/confirm : transforme la place retenue en réservation (410 si expirée).
+// - DELETE /holds/hld_123 : rend la place sans réserver.
+func (s *Server) holdsSub(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "holds" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	em := currentEmail(r)
+	id := services.ID(parts[1])
+
+	// /holds/:id/confirm
+	if len(parts) == 3 && parts[2] == "confirm" && r.Method == http.MethodPost {
+		res, err := s.Booking.ConfirmHold(id, em)
+		if err != nil {
+			writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, res)
+		return
+	}
+
+	// /holds/:id
+	if len(parts) == 2 && r.Method == http.MethodDelete {
+		if err := s.Booking.ReleaseHold(id, em); err != nil {
+			writeJSON(w, errorStatus(err), map[string]string{"error": err.Error()})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+		return
+	}
+
 	w.WriteHeader(http.StatusMethodNotAllowed)
 }
\ No newline at end of file
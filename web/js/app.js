@@ -11,15 +11,32 @@ function setEmail(value) {
   updateWho();
 }
 
+// Permissions du rôle connecté (renvoyées par /auth/login et /auth/me)
+function setPermissions(list) {
+  localStorage.setItem('permissions', JSON.stringify(list || []));
+}
+
+function can(permission) {
+  try {
+    return JSON.parse(localStorage.getItem('permissions') || '[]').includes(permission);
+  } catch {
+    return false;
+  }
+}
+
 function updateWho() {
   const whoElement = document.getElementById('who');
   if (!whoElement) return;
   whoElement.textContent = getEmail() || 'aucun';
 }
 
-function logout() {
-  // Supprime l'email stocké
+async function logout() {
+  // Ferme la session côté serveur (le cookie devient invalide)
+  await api('/auth/logout', { method: 'POST' });
+
+  // Supprime l'email et les permissions stockés
   localStorage.removeItem('email');
+  localStorage.removeItem('permissions');
 
   // Met à jour le texte "Connecté: ..."
   updateWho();
@@ -86,6 +103,12 @@ function escapeHtml(str) {
   });
 }
 
+// --------- Helper : liste saisie "a,b,c" ---------
+
+function splitList(value) {
+  return (value || '').split(',').map((s) => s.trim()).filter(Boolean);
+}
+
 // --------- Cache services/slots pour réutilisation ---------
 const svcCache = {
   services: [],
@@ -115,14 +138,15 @@ function updateSlotCatalogCache(services = [], slotsByService = {}) {
   });
 }
 
-async function fetchServicesWithSlots() {
+// query : filtres de la liste des créneaux (ex : '?full=false&past=false')
+async function fetchServicesWithSlots(query = '') {
   const services = await apiGet('/services');
   if (!Array.isArray(services)) {
     return null;
   }
 
   const results = await Promise.allSettled(
-    services.map((service) => apiGet(`/services/${service.id}/slots`))
+    services.map((service) => apiGet(`/services/${service.id}/slots${query}`))
   );
 
   const slotsByService = {};
@@ -150,6 +174,14 @@ async function ensureSlotCatalog() {
   return svcCache.slotCatalog;
 }
 
+// Libellés des statuts de réservation renvoyés par l'API
+const STATUS_LABELS = {
+  confirmed: 'confirmée',
+  cancelled: 'annulée',
+  attended: 'présent',
+  no_show: 'absent',
+};
+
 function formatReservation(reservation, slotCatalog = {}) {
   const slotInfo = slotCatalog[reservation.slotId] || null;
   const serviceLabel = slotInfo
@@ -165,27 +197,54 @@ function formatReservation(reservation, slotCatalog = {}) {
   const createdHtml = createdAt
     ? `<div class="muted">${escapeHtml(createdAt)}</div>`
     : '';
+  const status = (STATUS_LABELS[reservation.status] || reservation.status || STATUS_LABELS.confirmed)
+    + (reservation.lateCancel ? ' (hors délai)' : '');
 
   return `
     <div class="res-item">
       <div><b>${escapeHtml(serviceLabel)}</b></div>
       <div>Créneau : ${escapeHtml(slotDatetime)}</div>
+      <div>Places : ${escapeHtml(String(reservation.seats || 1))}</div>
+      <div>Statut : ${escapeHtml(status)}</div>
       <div class="muted">ID réservation : ${escapeHtml(reservation.id || '')}</div>
       ${createdHtml}
     </div>
   `;
 }
 
-function renderReservations(reservations, slotCatalog) {
+function formatWaitlistEntry(entry, slotCatalog = {}) {
+  const slotInfo = slotCatalog[entry.slotId] || null;
+  const serviceLabel = slotInfo
+    ? slotInfo.serviceLabel
+    : `Créneau ${entry.slotId || 'inconnu'}`;
+  const slotDatetime = slotInfo && slotInfo.datetime
+    ? slotInfo.datetime
+    : 'Date inconnue';
+
+  return `
+    <div class="res-item">
+      <div><b>${escapeHtml(serviceLabel)}</b> – liste d'attente, position ${escapeHtml(String(entry.position))}</div>
+      <div>Créneau : ${escapeHtml(slotDatetime)}</div>
+      <div class="muted">ID inscription : ${escapeHtml(entry.id || '')}</div>
+    </div>
+  `;
+}
+
+function renderReservations(reservations, waitlist, slotCatalog) {
   if (!el.resBox) return;
 
-  if (!Array.isArray(reservations) || reservations.length === 0) {
+  reservations = Array.isArray(reservations) ? reservations : [];
+  waitlist = Array.isArray(waitlist) ? waitlist : [];
+
+  if (reservations.length === 0 && waitlist.length === 0) {
     el.resBox.innerHTML = '<i>(aucune réservation)</i>';
     return;
   }
 
-  const html = reservations
-    .map((res) => formatReservation(res, slotCatalog))
+  const html = [
+    ...reservations.map((res) => formatReservation(res, slotCatalog)),
+    ...waitlist.map((entry) => formatWaitlistEntry(entry, slotCatalog)),
+  ]
     .join('')
     .trim();
 
@@ -211,10 +270,21 @@ function renderServicesFormatted(services, slotsByService) {
               const slotId = slot.id
                 ? escapeHtml(slot.id)
                 : '[id inconnu]';
-              const slotDateTime = slot.datetime
-                ? escapeHtml(slot.datetime)
+              // Heure locale du créneau (fuseau du service), UTC à défaut
+              const start = slot.localStart || slot.datetime;
+              const slotDateTime = start
+                ? escapeHtml(start)
                 : '[date inconnue]';
-              return `${slotId} – ${slotDateTime}`;
+              const slotEnd = slot.localEnd && slot.end !== slot.start
+                ? ` → ${escapeHtml(slot.localEnd)}`
+                : '';
+              const slotZone = slot.timeZone
+                ? ` (${escapeHtml(slot.timeZone)})`
+                : '';
+              const slotSeats = slot.full
+                ? ' – complet'
+                : ` – ${escapeHtml(String(slot.remaining ?? slot.capacity))}/${escapeHtml(String(slot.capacity))} place(s)`;
+              return `${slotId} – ${slotDateTime}${slotEnd}${slotZone}${slotSeats}`;
             })
             .join(', ')
         : '(aucun)';
@@ -240,33 +310,81 @@ const el = {
   // Connexion utilisateur
   loginForm: document.getElementById('loginForm'),
   emailInput: document.getElementById('emailInput'),
+  passwordInput: document.getElementById('passwordInput'),
+  registerBtn: document.getElementById('registerBtn'),
   logoutBtn: document.getElementById('logoutBtn'),
 
   // Chargement et affichage des services
   btnLoadSvc: document.getElementById('btnLoadServices'),
+  svcOpenOnly: document.getElementById('svcOpenOnly'),
   svcList: document.getElementById('svcList'),
 
+  // Recherche de disponibilités
+  availForm: document.getElementById('availForm'),
+  availFrom: document.getElementById('availFrom'),
+  availTo: document.getElementById('availTo'),
+  availSeats: document.getElementById('availSeats'),
+  availList: document.getElementById('availList'),
+
   // Réservation
   bookForm: document.getElementById('bookForm'),
   slotIdInput: document.getElementById('slotIdInput'),
+  seatsInput: document.getElementById('seatsInput'),
 
   // Consultation / annulation de réservation
   btnLoadMyRes: document.getElementById('btnLoadMyRes'),
   resBox: document.getElementById('resBox'),
   cancelForm: document.getElementById('cancelForm'),
   resIdInput: document.getElementById('resIdInput'),
+  moveForm: document.getElementById('moveForm'),
+  moveSlotInput: document.getElementById('moveSlotInput'),
 
   // Gestion des services (admin)
   addSvcForm: document.getElementById('addSvcForm'),
   svcName: document.getElementById('svcName'),
   svcDesc: document.getElementById('svcDesc'),
   svcDur: document.getElementById('svcDur'),
+  svcMaxParty: document.getElementById('svcMaxParty'),
+  svcNotice: document.getElementById('svcNotice'),
+  svcMaxResched: document.getElementById('svcMaxResched'),
+  svcAllowLate: document.getElementById('svcAllowLate'),
+  svcNoOverlap: document.getElementById('svcNoOverlap'),
+  svcOpenDays: document.getElementById('svcOpenDays'),
+  svcOpenHours: document.getElementById('svcOpenHours'),
+  svcHolidays: document.getElementById('svcHolidays'),
+  svcTimeZone: document.getElementById('svcTimeZone'),
+
+  // Créneaux en conflit avec le calendrier (admin)
+  conflictsForm: document.getElementById('conflictsForm'),
+  conflictsSvcId: document.getElementById('conflictsSvcId'),
+
+  // Suppression de service (admin)
+  delSvcForm: document.getElementById('delSvcForm'),
+  delSvcId: document.getElementById('delSvcId'),
+  delSvcCascade: document.getElementById('delSvcCascade'),
 
   // Gestion des créneaux (admin)
   addSlotForm: document.getElementById('addSlotForm'),
   slotSvcId: document.getElementById('slotSvcId'),
   slotDt: document.getElementById('slotDt'),
   slotCap: document.getElementById('slotCap'),
+  slotRes: document.getElementById('slotRes'),
+
+  // Créneaux récurrents (admin)
+  addSeriesForm: document.getElementById('addSeriesForm'),
+  seriesSvcId: document.getElementById('seriesSvcId'),
+  seriesDays: document.getElementById('seriesDays'),
+  seriesTimes: document.getElementById('seriesTimes'),
+  seriesFrom: document.getElementById('seriesFrom'),
+  seriesUntil: document.getElementById('seriesUntil'),
+  seriesCap: document.getElementById('seriesCap'),
+  seriesRes: document.getElementById('seriesRes'),
+
+  // Ressources (admin)
+  addResourceForm: document.getElementById('addResourceForm'),
+  resName: document.getElementById('resName'),
+  resKind: document.getElementById('resKind'),
+  listResourcesBtn: document.getElementById('listResourcesBtn'),
 
   // Zone d’affichage admin
   adminOut: document.getElementById('adminOut'),
@@ -275,40 +393,133 @@ const el = {
 // --------- Init ---------
 updateWho();
 
+// Lien d'annulation reçu par email : /?cancel=res_... pré-remplit le formulaire
+const cancelParam = new URLSearchParams(window.location.search).get('cancel');
+if (cancelParam) {
+  el.resIdInput.value = cancelParam;
+}
+
+// Resynchronise l'email affiché avec la session réelle (cookie expiré, etc.)
+api('/auth/me').then(({ ok, body }) => {
+  setEmail(ok ? body.email : '');
+  setPermissions(ok ? body.permissions : []);
+});
+
 // --------- Events ---------
-el.loginForm.addEventListener('submit', async (e) => {
-  e.preventDefault();
+// Connexion / inscription : le serveur pose un cookie de session (HttpOnly),
+// envoyé automatiquement par fetch sur les appels suivants.
+async function authenticate(path) {
   const userEmail = el.emailInput.value.trim();
-  if (!userEmail) return alert('Entre un email');
+  const password = el.passwordInput.value;
+  if (!userEmail) return alert('Entre ton email');
+
+  // Connexion sans mot de passe : le serveur envoie un lien par email
+  if (!password && path === '/auth/login') {
+    const { ok, body } = await api(path, {
+      method: 'POST',
+      body: JSON.stringify({ email: userEmail }),
+    });
+    alert(ok ? `Lien de connexion envoyé à ${userEmail}` : body?.error || 'Erreur');
+    return;
+  }
+
+  if (!password) return alert('Entre un mot de passe (8 caractères minimum)');
 
-  await api('/auth/login', {
+  const { ok, body } = await api(path, {
     method: 'POST',
-    body: JSON.stringify({ email: userEmail }),
+    body: JSON.stringify({ email: userEmail, password }),
   });
 
-  setEmail(userEmail);
+  if (!ok) {
+    alert(body?.error || 'Erreur de connexion');
+    return;
+  }
+
+  el.passwordInput.value = '';
+  setEmail(body.email);
+  setPermissions(body.permissions);
+}
+
+el.loginForm.addEventListener('submit', async (e) => {
+  e.preventDefault();
+  await authenticate('/auth/login');
+});
+
+el.registerBtn.addEventListener('click', async () => {
+  await authenticate('/auth/register');
 });
 
 // Déconnexion
 if (el.logoutBtn) {
-  el.logoutBtn.addEventListener('click', () => {
-    logout();
+  el.logoutBtn.addEventListener('click', async () => {
+    await logout();
   });
 }
 
 // --------- Charger les services (et slots si l’endpoint existe) ---------
 el.btnLoadSvc.addEventListener('click', async () => {
-  const svcData = await fetchServicesWithSlots();
+  // Créneaux libres à venir seulement : filtrés par le serveur
+  const openOnly = el.svcOpenOnly.checked;
+  const svcData = await fetchServicesWithSlots(openOnly ? '?full=false&past=false' : '');
 
   if (!svcData) {
     el.svcList.innerHTML = '<i>(erreur chargement)</i>';
     return;
   }
 
-  updateSlotCatalogCache(svcData.services, svcData.slotsByService);
+  // Le catalogue sert aussi à afficher les réservations : liste complète seulement
+  if (!openOnly) {
+    updateSlotCatalogCache(svcData.services, svcData.slotsByService);
+  }
   renderServicesFormatted(svcData.services, svcData.slotsByService);
 });
 
+// --------- Disponibilités (tous services, un seul appel) ---------
+el.availForm.addEventListener('submit', async (e) => {
+  e.preventDefault();
+
+  const params = new URLSearchParams();
+  if (el.availFrom.value) params.set('from', el.availFrom.value);
+  if (el.availTo.value) params.set('to', el.availTo.value);
+  params.set('minSeats', String(Number(el.availSeats.value || 1) || 1));
+
+  const { ok, body } = await api(`/availability?${params}`);
+  if (!ok) {
+    el.availList.innerHTML = `<i>${escapeHtml(body?.error || 'Erreur')}</i>`;
+    return;
+  }
+
+  el.availList.innerHTML = body.length
+    ? body
+        .map((a) => `
+          <div class="svc-item">
+            <div><b>${escapeHtml(a.serviceName)}</b> – ${escapeHtml(a.localStart)} (${escapeHtml(a.timeZone || '')})</div>
+            <div>${escapeHtml(String(a.remaining))} place(s) libre(s)</div>
+            <div class="muted">Slot ID : ${escapeHtml(a.id)}</div>
+          </div>
+        `)
+        .join('')
+    : '<i>(aucun créneau disponible)</i>';
+});
+
+// Refus dus aux limites de réservation par utilisateur (voir BookingLimits) :
+// l'API renvoie l'erreur suivie de la limite, ex : "too many upcoming reservations (max 5)"
+const LIMIT_MESSAGES = {
+  'too many upcoming reservations for this service': 'Tu as déjà atteint le nombre maximum de réservations à venir pour ce service',
+  'too many upcoming reservations': 'Tu as déjà atteint le nombre maximum de réservations à venir',
+  'too many reservations on that day': 'Tu as déjà atteint le nombre maximum de réservations pour ce jour',
+  'too late to book this slot': 'Trop tard pour réserver ce créneau',
+};
+
+function limitMessage(error) {
+  if (!error) return null;
+  // Le préfixe le plus long d'abord ("... for this service" avant "...")
+  const key = Object.keys(LIMIT_MESSAGES)
+    .sort((a, b) => b.length - a.length)
+    .find((k) => error.startsWith(k));
+  return key ? `${LIMIT_MESSAGES[key]} (${error})` : null;
+}
+
 // --------- Réserver ---------
 el.bookForm.addEventListener('submit', async (e) => {
   e.preventDefault();
@@ -324,15 +535,29 @@ el.bookForm.addEventListener('submit', async (e) => {
     alert('Slot ID requis');
     return;
   }
+  const seats = Number(el.seatsInput.value || 1) || 1;
 
-  const { ok, body } = await api('/reservations', {
+  let { ok, status, body } = await api('/reservations', {
     method: 'POST',
-    headers: { 'X-User-Email': userEmail },
-    body: JSON.stringify({ slotId }),
+    body: JSON.stringify({ slotId, seats }),
   });
 
+  // Créneau complet : proposer la liste d'attente
+  if (!ok && body?.error === 'slot is full'
+      && confirm('Créneau complet. Rejoindre la liste d\'attente ?')) {
+    ({ ok, status, body } = await api('/reservations', {
+      method: 'POST',
+      body: JSON.stringify({ slotId, seats, waitlist: true }),
+    }));
+  }
+
   if (!ok) {
-    alert(body?.error || 'Erreur réservation');
+    alert(limitMessage(body?.error) || body?.error || 'Erreur réservation');
+    return;
+  }
+
+  if (status === 202) {
+    alert(`Liste d'attente : position ${body.position} (${body.id})`);
     return;
   }
 
@@ -348,25 +573,37 @@ el.btnLoadMyRes.addEventListener('click', async () => {
     return;
   }
 
-  const { ok, body } = await api('/reservations/me', {
-    method: 'GET',
-    headers: { 'X-User-Email': userEmail },
-  });
+  const [res, wait] = await Promise.all([
+    api('/reservations/me', { method: 'GET' }),
+    api('/waitlist/me', { method: 'GET' }),
+  ]);
 
-  if (!ok) {
+  if (!res.ok || !wait.ok) {
     el.resBox.innerHTML = '<i>(erreur)</i>';
     return;
   }
 
-  if (!Array.isArray(body) || body.length === 0) {
+  const reservations = Array.isArray(res.body) ? res.body : [];
+  const waitlist = Array.isArray(wait.body) ? wait.body : [];
+  if (reservations.length === 0 && waitlist.length === 0) {
     el.resBox.innerHTML = '<i>(aucune réservation)</i>';
     return;
   }
 
   const slotCatalog = await ensureSlotCatalog();
-  renderReservations(body, slotCatalog);
+  renderReservations(reservations, waitlist, slotCatalog);
 });
 
+// Message d'erreur d'annulation / déplacement ; hors délai, l'API
+// renvoie la date limite ("deadline")
+function changeErrorMessage(body, fallback) {
+  if (body?.deadline) {
+    const deadline = new Date(body.deadline).toLocaleString('fr-FR');
+    return `Délai dépassé : modification possible jusqu'au ${deadline}`;
+  }
+  return limitMessage(body?.error) || body?.error || fallback;
+}
+
 // --------- Annuler ---------
 el.cancelForm.addEventListener('submit', async (e) => {
   e.preventDefault();
@@ -383,26 +620,59 @@ el.cancelForm.addEventListener('submit', async (e) => {
     return;
   }
 
-  const { ok, body } = await api(`/reservations/${reservationId}`, {
+  // Les inscriptions en liste d'attente (wl_...) ont leur propre route
+  const path = reservationId.startsWith('wl_')
+    ? `/reservations/waitlist/${reservationId}`
+    : `/reservations/${reservationId}`;
+
+  const { ok, body } = await api(path, {
     method: 'DELETE',
-    headers: { 'X-User-Email': userEmail },
   });
 
   if (!ok) {
-    alert(body?.error || 'Erreur annulation');
+    alert(changeErrorMessage(body, 'Erreur annulation'));
     return;
   }
 
   alert('Annulée');
 });
 
+// --------- Déplacer ---------
+el.moveForm.addEventListener('submit', async (e) => {
+  e.preventDefault();
+
+  const userEmail = email();
+  if (!userEmail) {
+    alert('Connecte-toi');
+    return;
+  }
+
+  const reservationId = el.resIdInput.value.trim();
+  const slotId = el.moveSlotInput.value.trim();
+  if (!reservationId || !slotId) {
+    alert('Reservation ID et nouveau slot ID requis');
+    return;
+  }
+
+  const { ok, body } = await api(`/reservations/${reservationId}`, {
+    method: 'PATCH',
+    body: JSON.stringify({ slotId }),
+  });
+
+  if (!ok) {
+    alert(changeErrorMessage(body, 'Erreur déplacement'));
+    return;
+  }
+
+  alert(`Réservation déplacée : ${body.id}`);
+});
+
 // --------- Admin : créer un service ---------
 el.addSvcForm.addEventListener('submit', async (e) => {
   e.preventDefault();
 
-  const userEmail = email();
-  if (userEmail !== 'admin@example.com') {
-    alert('Action admin : connecte-toi en admin@example.com');
+  if (!can('services:manage')) {
+    alert('Action réservée aux managers et administrateurs');
     return;
   }
 
@@ -410,6 +680,14 @@ el.addSvcForm.addEventListener('submit', async (e) => {
     name: el.svcName.value.trim(),
     description: el.svcDesc.value.trim(),
     duration: Number(el.svcDur.value || 0) || 0,
+    maxPartySize: Number(el.svcMaxParty.value || 0) || 0,
+    cancellation: {
+      noticeMinutes: Number(el.svcNotice.value || 0) || 0,
+      maxReschedules: Number(el.svcMaxResched.value || 0) || 0,
+      allowLate: el.svcAllowLate.checked,
+    },
+    noOverlap: el.svcNoOverlap.checked,
+    timeZone: el.svcTimeZone.value.trim(),
   };
 
   if (!service.name) {
@@ -417,9 +695,22 @@ el.addSvcForm.addEventListener('submit', async (e) => {
     return;
   }
 
+  // Calendrier : une plage d’ouverture (mêmes heures chaque jour ouvert)
+  const openDays = splitList(el.svcOpenDays.value);
+  const [open, close] = el.svcOpenHours.value.split('-').map((s) => s.trim());
+  if (openDays.length || el.svcOpenHours.value.trim() || el.svcHolidays.checked) {
+    service.calendar = { frenchHolidays: el.svcHolidays.checked };
+    if (openDays.length || open || close) {
+      if (!openDays.length || !open || !close) {
+        alert('Jours et horaires d’ouverture requis ensemble (ex : MO,TU et 09:00-18:00)');
+        return;
+      }
+      service.calendar.hours = [{ days: openDays, open, close }];
+    }
+  }
+
   const { ok, body } = await api('/admin/services', {
     method: 'POST',
-    headers: { 'X-User-Email': userEmail },
     body: JSON.stringify(service),
   });
 
@@ -428,19 +719,72 @@ el.addSvcForm.addEventListener('submit', async (e) => {
     : body?.error || 'Erreur';
 });
 
+// --------- Admin : supprimer un service ---------
+el.delSvcForm.addEventListener('submit', async (e) => {
+  e.preventDefault();
+
+  if (!can('services:manage')) {
+    alert('Action réservée aux managers et administrateurs');
+    return;
+  }
+
+  const serviceId = el.delSvcId.value.trim();
+  if (!serviceId) {
+    alert('Service ID requis');
+    return;
+  }
+
+  const cascade = el.delSvcCascade.checked ? '?cascade=true' : '';
+  const { ok, body } = await api(`/admin/services/${serviceId}${cascade}`, {
+    method: 'DELETE',
+  });
+
+  el.adminOut.textContent = ok
+    ? `Service supprimé. Réservations annulées : ${body.cancelled.length}`
+    : body?.error || 'Erreur';
+});
+
+// --------- Admin : créneaux hors calendrier ---------
+el.conflictsForm.addEventListener('submit', async (e) => {
+  e.preventDefault();
+
+  if (!can('slots:manage')) {
+    alert('Action réservée au personnel (staff, manager, admin)');
+    return;
+  }
+
+  const serviceId = el.conflictsSvcId.value.trim();
+  if (!serviceId) {
+    alert('Service ID requis');
+    return;
+  }
+
+  const { ok, body } = await api(`/admin/services/${serviceId}/conflicts`);
+  if (!ok) {
+    el.adminOut.textContent = body?.error || 'Erreur';
+    return;
+  }
+
+  el.adminOut.textContent = body.length
+    ? body
+        .map((c) => `${c.slot.id} – ${c.slot.localStart} : ${c.reason} (${c.reservations} réservation(s))`)
+        .join('\n')
+    : 'Aucun créneau à venir hors calendrier.';
+});
+
 // --------- Admin : ajouter un créneau ---------
 el.addSlotForm.addEventListener('submit', async (e) => {
   e.preventDefault();
 
-  const userEmail = email();
-  if (userEmail !== 'admin@example.com') {
-    alert('Action admin : connecte-toi en admin@example.com');
+  if (!can('slots:manage')) {
+    alert('Action réservée au personnel (staff, manager, admin)');
     return;
   }
 
   const serviceId = el.slotSvcId.value.trim();
   const dateTime = el.slotDt.value.trim();
   const capacity = Number(el.slotCap.value || 1) || 1;
+  const resources = splitList(el.slotRes.value);
 
   if (!serviceId || !dateTime) {
     alert('Service ID et Date/Heure requis');
@@ -449,11 +793,81 @@ el.addSlotForm.addEventListener('submit', async (e) => {
 
   const { ok, body } = await api(`/admin/services/${serviceId}/slots`, {
     method: 'POST',
-    headers: { 'X-User-Email': userEmail },
-    body: JSON.stringify({ datetime: dateTime, capacity }),
+    body: JSON.stringify({ datetime: dateTime, capacity, resources }),
   });
 
   el.adminOut.textContent = ok
     ? `Créneau ajouté. Slot ID : ${body.id}\nCopie cet ID pour réserver.`
     : body?.error || 'Erreur';
 });
+
+// --------- Admin : ajouter une série de créneaux ---------
+el.addSeriesForm.addEventListener('submit', async (e) => {
+  e.preventDefault();
+
+  if (!can('slots:manage')) {
+    alert('Action réservée au personnel (staff, manager, admin)');
+    return;
+  }
+
+  const serviceId = el.seriesSvcId.value.trim();
+  const weekly = {
+    days: splitList(el.seriesDays.value),
+    times: splitList(el.seriesTimes.value),
+    from: el.seriesFrom.value.trim(),
+    until: el.seriesUntil.value.trim(),
+  };
+  const capacity = Number(el.seriesCap.value || 1) || 1;
+  const resources = splitList(el.seriesRes.value);
+
+  if (!serviceId || !weekly.days.length || !weekly.times.length || !weekly.from || !weekly.until) {
+    alert('Service ID, jours, heures et période requis');
+    return;
+  }
+
+  const { ok, body } = await api(`/admin/services/${serviceId}/slots/recurring`, {
+    method: 'POST',
+    body: JSON.stringify({ weekly, capacity, resources }),
+  });
+
+  el.adminOut.textContent = ok
+    ? `Série ${body.seriesId} : ${body.slots.length} créneaux ajoutés.`
+    : body?.error || 'Erreur';
+});
+
+// --------- Admin : ressources (personnel, salles, équipements) ---------
+el.addResourceForm.addEventListener('submit', async (e) => {
+  e.preventDefault();
+
+  if (!can('services:manage')) {
+    alert('Action réservée aux managers et administrateurs');
+    return;
+  }
+
+  const name = el.resName.value.trim();
+  if (!name) {
+    alert('Nom requis');
+    return;
+  }
+
+  const { ok, body } = await api('/admin/resources', {
+    method: 'POST',
+    body: JSON.stringify({ name, kind: el.resKind.value }),
+  });
+
+  el.adminOut.textContent = ok
+    ? `Ressource créée : ${body.id}`
+    : body?.error || 'Erreur';
+});
+
+el.listResourcesBtn.addEventListener('click', async () => {
+  if (!can('slots:manage')) {
+    alert('Action réservée au personnel (staff, manager, admin)');
+    return;
+  }
+
+  const { ok, body } = await api('/admin/resources');
+  el.adminOut.textContent = ok
+    ? body.map((r) => `${r.id} – ${r.name} (${r.kind})`).join('\n') || '(aucune ressource)'
+    : body?.error || 'Erreur';
+});